@@ -0,0 +1,491 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/afonso-borges/t-hub/internal/utils"
+)
+
+const usage = `Usage:
+  t-hub                      start the interactive loot split calculator
+  t-hub split [flags] [files] split Party Hunt analyzers read from stdin or files
+  t-hub session [flags]      record a solo Hunting Session analyzer read from stdin or --file
+  t-hub history              list past splits and sessions
+  t-hub history show <id>    print a past split
+  t-hub history copy <id>    copy a past split to the clipboard
+  t-hub history export <id>  print a past split as JSON
+  t-hub debts                show unpaid transfers and balances per character
+  t-hub debts pay <ids>      mark transfers as paid
+  t-hub debts unpay <ids>    mark transfers as unpaid again
+  t-hub debts net [--apply]  consolidate unpaid transfers into the fewest bank transfers
+  t-hub template             print the default clipboard template
+
+Run "t-hub <command> -h" for the flags of a command.
+`
+
+// runCommand dispatches the non-interactive subcommands
+func runCommand(name string, args []string) error {
+	switch name {
+	case "split":
+		return runSplit(args)
+	case "session":
+		return runSession(args)
+	case "history":
+		return runHistory(args)
+	case "debts":
+		return runDebts(args)
+	case "template":
+		fmt.Println(utils.DefaultTemplate)
+		return nil
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ContinueOnError)
+	var files fileList
+	fs.Var(&files, "file", "read an analyzer from `path` instead of stdin; repeat to settle several hunts at once")
+	exclude := fs.String("exclude", "", "comma-separated `names` of players to leave out of the split")
+	excludeMode := fs.String("exclude-mode", "ignore", "what excluded players settle: ignore, loot to hand over their loot, or refund to also get their supplies refunded; --strategy reimburse always refunds them")
+	weights := fs.String("weights", "", "comma-separated share `weights` such as \"Name=1.2,Other=0.5\"; unlisted players take 1 share")
+	remainder := fs.String("remainder", "leader", "who keeps the gold lost to rounding: leader, damage, round-robin or payer")
+	noHistory := fs.Bool("no-history", false, "do not save the split to the local history")
+	solverName := fs.String("solver", "greedy", "how transfers are matched: greedy, or exact for the true minimum on small parties")
+	strategyName := fs.String("strategy", "equal", "how the balance is shared: equal, or reimburse to refund everyone's supplies, excluded players' too, before splitting the rest")
+	contribution := fs.String("contribution", "0", "`percent` of the balance shared by damage and healing contribution instead of equally")
+	healing := fs.String("healing", "50", "`percent` of the contribution measured by healing instead of damage")
+	csvPath := fs.String("csv", "", "append one row per player to the CSV spreadsheet at `path`")
+	format := fs.String("format", "plain", "how the split is printed: plain, discord or chat")
+	asJSON := fs.Bool("json", false, "print the split as versioned JSON instead of text")
+	reprice := fs.Bool("reprice", false, "re-value the looted items with the price table in the data directory")
+	pricesPath := fs.String("prices", "", "re-value the looted items with the price table at `path` (JSON or CSV)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	shareWeights, err := parseWeights(*weights)
+	if err != nil {
+		return err
+	}
+
+	policy, err := utils.ParseRemainderPolicy(*remainder)
+	if err != nil {
+		return err
+	}
+
+	solver, err := utils.ParseSolver(*solverName)
+	if err != nil {
+		return err
+	}
+
+	strategy, err := utils.ParseSplitStrategy(*strategyName)
+	if err != nil {
+		return err
+	}
+
+	exclusion, err := utils.ParseExclusionMode(*excludeMode)
+	if err != nil {
+		return err
+	}
+
+	formatter, err := utils.ParseFormatter(*format)
+	if err != nil {
+		return err
+	}
+
+	var blend utils.ContributionBlend
+	if blend.Contribution, err = utils.ParsePercent(*contribution); err != nil {
+		return err
+	}
+	if blend.Healing, err = utils.ParsePercent(*healing); err != nil {
+		return err
+	}
+
+	files = append(files, fs.Args()...)
+	analyzers, party, players, err := loadSessions(files)
+	if err != nil {
+		return err
+	}
+
+	if *reprice || *pricesPath != "" {
+		party, players, err = repriceLoot(party, players, *pricesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := checkPlayerNames("weights", slices.Sorted(maps.Keys(shareWeights)), players); err != nil {
+		return err
+	}
+
+	excluded := splitNames(*exclude)
+	if err := checkPlayerNames("exclude", excluded, players); err != nil {
+		return err
+	}
+	remainingPlayers := utils.FilterRemainingPlayers(players, excluded)
+	if len(remainingPlayers) == 0 {
+		return errors.New("no players left to split the loot between")
+	}
+
+	split := utils.CalculateGoldSplit(remainingPlayers,
+		utils.WithWeights(shareWeights),
+		utils.WithRemainderPolicy(policy),
+		utils.WithSolver(solver),
+		utils.WithStrategy(strategy),
+		utils.WithExcluded(utils.FilterExcludedPlayers(players, excluded), exclusion),
+		utils.WithDuration(party.Duration),
+		utils.WithContributionBlend(blend))
+
+	// Save first so the JSON export carries the hunt and transfer IDs
+	entry := utils.HistoryEntry{
+		Analyzers: analyzers,
+		Party:     party,
+		Players:   players,
+		Excluded:  excluded,
+		Split:     split,
+	}
+	var saveErr error
+	var duplicate bool
+	if !*noHistory {
+		entry, saveErr = utils.SaveHistory(entry)
+		switch {
+		case errors.Is(saveErr, utils.ErrDuplicateHunt):
+			// Running the same split again, e.g. from a script, is not an
+			// error but must not save or append it twice
+			fmt.Fprintf(os.Stderr, "warning: %v, not saved again\n", saveErr)
+			duplicate, saveErr = true, nil
+		case saveErr == nil && entry.Replaces != "":
+			fmt.Fprintf(os.Stderr, "replaced the earlier split %s of this hunt\n", entry.Replaces)
+		}
+	}
+
+	if *asJSON {
+		data, err := utils.ExportJSON(entry.ID, party, players, entry.Split)
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(data)
+	} else {
+		text, err := formatter.Format(party, entry.Split)
+		if err != nil {
+			return err
+		}
+		fmt.Print(text)
+	}
+
+	if *csvPath != "" && duplicate {
+		fmt.Fprintf(os.Stderr, "warning: not appended to %s again\n", *csvPath)
+	} else if *csvPath != "" {
+		if err := utils.AppendCSV(*csvPath, entry.ID, party, entry.Split); err != nil {
+			return err
+		}
+	}
+	return saveErr
+}
+
+// repriceLoot re-values the loot with the price table at path, or the one in
+// the data directory when path is empty
+func repriceLoot(party utils.Party, players []utils.Player, path string) (utils.Party, []utils.Player, error) {
+	var prices utils.PriceTable
+	var err error
+	if path != "" {
+		prices, err = utils.ReadPriceTable(path)
+	} else {
+		prices, err = utils.LoadPriceTable()
+		if err == nil && prices == nil {
+			err = errors.New("no prices.json or prices.csv in the data directory")
+		}
+	}
+	if err != nil {
+		return party, players, err
+	}
+
+	party, players, warnings := utils.RepriceLoot(party, players, prices)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return party, players, nil
+}
+
+func runHistory(args []string) error {
+	if len(args) == 0 {
+		entries, err := utils.LoadHistory()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fmt.Printf("%-18s %s\n", entry.ID, entry.Title())
+		}
+		return nil
+	}
+
+	if len(args) != 2 || (args[0] != "show" && args[0] != "copy" && args[0] != "export") {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("usage: t-hub history [show|copy|export <id>]")
+	}
+
+	entry, err := utils.LoadHistoryEntry(args[1])
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "copy":
+		if entry.Session == nil {
+			return utils.SaveToClipboard(entry.Party, entry.Split)
+		}
+		text, err := entry.Format()
+		if err != nil {
+			return err
+		}
+		return utils.WriteClipboard(text)
+	case "export":
+		if entry.Session != nil {
+			return fmt.Errorf("%s is a solo session, only splits can be exported", entry.ID)
+		}
+		data, err := utils.ExportJSON(entry.ID, entry.Party, entry.Players, entry.Split)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	text, err := entry.Format()
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
+
+func runSession(args []string) error {
+	fs := flag.NewFlagSet("session", flag.ContinueOnError)
+	file := fs.String("file", "", "read the analyzer from `path` instead of stdin")
+	noHistory := fs.Bool("no-history", false, "do not save the session to the local history")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	analyzer, err := readAnalyzer(*file)
+	if err != nil {
+		return err
+	}
+
+	session, warnings, err := utils.ParseHuntingSession(analyzer)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(utils.FormatHuntingSession(session))
+
+	if *noHistory {
+		return nil
+	}
+	_, err = utils.SaveHistory(utils.HistoryEntry{
+		Analyzers: []string{analyzer},
+		Session:   &session,
+	})
+	if errors.Is(err, utils.ErrDuplicateHunt) {
+		fmt.Fprintf(os.Stderr, "warning: %v, not saved again\n", err)
+		return nil
+	}
+	return err
+}
+
+// fileList collects the values of a repeatable flag
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *fileList) Set(path string) error {
+	*f = append(*f, path)
+	return nil
+}
+
+// loadSessions parses the analyzer of every file, or stdin when there are
+// none, and merges them into a single party
+func loadSessions(paths []string) ([]string, utils.Party, []utils.Player, error) {
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	var analyzers []string
+	var parties []utils.Party
+	var sessions [][]utils.Player
+	for _, path := range paths {
+		analyzer, err := readAnalyzer(path)
+		if err != nil {
+			return nil, utils.Party{}, nil, err
+		}
+
+		prefix := ""
+		if path != "" {
+			prefix = path + ": "
+		}
+
+		if utils.IsHuntingSession(analyzer) {
+			return nil, utils.Party{}, nil, fmt.Errorf("%sthis is a solo Hunting Session analyzer, use \"t-hub session\"", prefix)
+		}
+
+		party, players, warnings, err := utils.ParseAnalyzerWithWarnings(analyzer)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s%s\n", prefix, warning)
+		}
+		if err != nil {
+			return nil, utils.Party{}, nil, fmt.Errorf("%s%w", prefix, err)
+		}
+
+		analyzers = append(analyzers, analyzer)
+		parties = append(parties, party)
+		sessions = append(sessions, players)
+	}
+
+	return analyzers, utils.MergeParties(parties...), utils.MergePlayers(sessions...), nil
+}
+
+// readAnalyzer reads the analyzer text from path, or from stdin when path is empty
+func readAnalyzer(path string) (string, error) {
+	var r io.Reader = os.Stdin
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open analyzer: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read analyzer: %v", err)
+	}
+	return string(data), nil
+}
+
+// splitNames turns a comma-separated flag value into a list of trimmed names
+func splitNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseWeights parses a "Name=1.2,Other=0.5" flag value into share weights.
+// A piece without "=" is the decimal part of the previous weight, so
+// "Name=0,5" reads as half a share.
+func parseWeights(s string) (map[string]float64, error) {
+	var entries []string
+	for _, entry := range splitNames(s) {
+		if !strings.Contains(entry, "=") && len(entries) > 0 {
+			entries[len(entries)-1] += "," + entry
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	weights := make(map[string]float64)
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, expected Name=weight", entry)
+		}
+		w, err := utils.ParseWeight(value)
+		if err != nil {
+			return nil, err
+		}
+		weights[strings.TrimSpace(name)] = w
+	}
+	return weights, nil
+}
+
+// checkPlayerNames fails on the first name that isn't one of the players,
+// so a typo in a flag doesn't silently change the split
+func checkPlayerNames(flagName string, names []string, players []utils.Player) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(players, func(p utils.Player) bool { return p.Name == name }) {
+			return fmt.Errorf("unknown player %q in --%s", name, flagName)
+		}
+	}
+	return nil
+}
+
+func runDebts(args []string) error {
+	ledger, err := utils.LoadLedger()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		fmt.Print(utils.FormatDebts(ledger))
+		return nil
+	}
+
+	if args[0] == "net" {
+		return runNet(ledger, args[1:])
+	}
+
+	if len(args) < 2 || (args[0] != "pay" && args[0] != "unpay") {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("usage: t-hub debts [pay|unpay <ids>|net]")
+	}
+
+	for _, id := range args[1:] {
+		if err := ledger.SetPaid(id, args[0] == "pay"); err != nil {
+			return err
+		}
+	}
+	return ledger.Save()
+}
+
+func runNet(ledger *utils.Ledger, args []string) error {
+	fs := flag.NewFlagSet("debts net", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "replace the unpaid transfers with the netted ones in the ledger")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if !*apply {
+		netted := ledger.NetDebts()
+		if len(netted) == 0 {
+			fmt.Println("Nothing to settle.")
+		}
+		for _, transfer := range netted {
+			fmt.Printf("%s to pay %s %s   |   bank: %s\n",
+				transfer.From, transfer.To, utils.FormatNumber(transfer.Amount), transfer.BankCommand())
+		}
+		return nil
+	}
+
+	for _, transfer := range ledger.Net() {
+		fmt.Printf("[%s] %s to pay %s %s   |   bank: %s\n",
+			transfer.ID, transfer.From, transfer.To, utils.FormatNumber(transfer.Amount), transfer.BankCommand())
+	}
+	return ledger.Save()
+}
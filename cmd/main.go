@@ -1,9 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -63,8 +64,14 @@ type state int
 
 const (
 	stateWelcome state = iota
+	stateHistory
+	stateDebts
 	stateLoading
+	stateError
+	stateSoloSession
+	stateAddSession
 	statePlayerRemoval
+	stateSplitOptions
 	stateResults
 	stateStartOver
 	stateDone
@@ -78,9 +85,24 @@ type Model struct {
 	width           int
 	height          int
 	playersToRemove []string
-	analyzer        string
+	analyzers       []string
+	parties         []utils.Party
+	sessions        [][]utils.Player
+	duplicate       bool
+	party           utils.Party
 	players         []utils.Player
+	splitPlayers    []utils.Player
 	split           utils.GoldSplit
+	soloSession     *utils.HuntingSession
+	history         []utils.HistoryEntry
+	historyID       string
+	notice          string
+	warnings        []utils.Warning
+	prices          utils.PriceTable
+	resultsTab      int
+	contribution    utils.ContributionSort
+	bankCursor      int
+	bankNotice      string
 	loading         bool
 	spinner         spinner.Model
 }
@@ -108,9 +130,122 @@ func (m *Model) createWelcomeForm() {
 		huh.NewGroup(
 			huh.NewNote().
 				Title("Welcome to T-HUB").
-				Description("Make sure you have Party Hunt analyzer on your clipboard").
+				Description("Make sure you have a Party Hunt or Hunting Session analyzer on your clipboard"),
+			huh.NewSelect[string]().
+				Key("action").
+				Options(
+					huh.NewOption("Start a new loot split", "split"),
+					huh.NewOption("Browse past splits", "history"),
+					huh.NewOption("View outstanding debts", "debts"),
+				),
+		),
+	).
+		WithWidth(50).
+		WithShowHelp(false).
+		WithShowErrors(false)
+}
+
+func (m *Model) createHistoryForm() {
+	description := "Pick a past split to review it or copy it again"
+	if m.notice != "" {
+		description = m.notice
+	} else if len(m.history) == 0 {
+		description = "No splits saved yet"
+	}
+
+	var options []huh.Option[string]
+	for _, entry := range m.history {
+		options = append(options, huh.NewOption(entry.Title(), entry.ID))
+	}
+	options = append(options, huh.NewOption("Back", ""))
+
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Key("entry").
+				Title("Past splits").
+				Description(description).
+				Options(options...).
+				Height(min(len(options)+2, 12)),
+		),
+	).
+		WithWidth(50).
+		WithShowHelp(false).
+		WithShowErrors(false)
+}
+
+func (m *Model) createErrorForm(err error, warnings []utils.Warning) {
+	description := err.Error()
+	if len(warnings) > 0 {
+		description += "\n\n" + formatWarnings(warnings)
+	}
+
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Could not read the analyzer").
+				Description(description),
+			huh.NewSelect[string]().
+				Key("errorAction").
+				Description("Fix the analyzer on your clipboard and retry").
+				Options(
+					huh.NewOption("Retry", "retry"),
+					huh.NewOption("Back to menu", "menu"),
+					huh.NewOption("Exit", "exit"),
+				),
+		),
+	).
+		WithWidth(50).
+		WithShowHelp(false).
+		WithShowErrors(false)
+}
+
+func formatWarnings(warnings []utils.Warning) string {
+	var sb strings.Builder
+	sb.WriteString("Warnings:\n")
+	for _, warning := range warnings {
+		fmt.Fprintf(&sb, "• %s\n", warning)
+	}
+	return sb.String()
+}
+
+func (m *Model) createSoloSessionForm() {
+	description := utils.FormatHuntingSession(*m.soloSession)
+	if len(m.warnings) > 0 {
+		description += "\n" + formatWarnings(m.warnings)
+	}
+	if m.notice != "" {
+		description += "\n" + m.notice + "\n"
+	}
+
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Solo hunting session").
+				Description(description).
 				Next(true).
-				NextLabel("Start"),
+				NextLabel("Copy to clipboard"),
+		),
+	).
+		WithWidth(50).
+		WithShowHelp(false).
+		WithShowErrors(false)
+}
+
+func (m *Model) createAddSessionForm() {
+	description := fmt.Sprintf("%d hunt session(s) loaded with %d players.", len(m.sessions), len(m.players))
+	if m.duplicate {
+		description += "\nThe analyzer on your clipboard was already loaded, so it was skipped."
+	}
+	description += "\n\nTo settle several hunts at once, copy the next Party Hunt analyzer to your clipboard and choose Yes."
+
+	m.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Add another hunt session?").
+				Description(description).
+				Affirmative("Yes").
+				Negative("No, split now"),
 		),
 	).
 		WithWidth(50).
@@ -126,12 +261,19 @@ func (m *Model) createPlayerRemovalForm() {
 	m.playersToRemove = []string{}
 
 	playerOptions := utils.ExtractPlayerNames(m.players)
+	playerCount := len(m.players)
 
 	multiSelect := huh.NewMultiSelect[string]().
 		Title("Remove players from loot split?").
 		Description("Select players to exclude from the calculation").
 		Value(&m.playersToRemove).
-		Options(playerOptions...)
+		Options(playerOptions...).
+		Validate(func(names []string) error {
+			if len(names) >= playerCount {
+				return errors.New("at least one player must stay in the split")
+			}
+			return nil
+		})
 
 	m.form = huh.NewForm(
 		huh.NewGroup(multiSelect),
@@ -142,13 +284,197 @@ func (m *Model) createPlayerRemovalForm() {
 		WithTheme(themes.DefaultTheme())
 }
 
-func (m *Model) createResultsForm() {
+func (m *Model) createSplitOptionsForm() {
+	useWeights := new(bool)
+	useContribution := new(bool)
+	contribution, healing := "30", "50"
+
+	var inputs []huh.Field
+	for _, player := range m.splitPlayers {
+		weight := "1"
+		inputs = append(inputs, huh.NewInput().
+			Key("weight:"+player.Name).
+			Title(player.Name).
+			Value(&weight).
+			Validate(func(s string) error {
+				_, err := utils.ParseWeight(s)
+				return err
+			}))
+	}
+
+	var policyOptions []huh.Option[utils.RemainderPolicy]
+	for _, policy := range utils.RemainderPolicies {
+		policyOptions = append(policyOptions, huh.NewOption(policy.Description(), policy))
+	}
+
+	var strategyOptions []huh.Option[string]
+	for _, strategy := range utils.SplitStrategies {
+		strategyOptions = append(strategyOptions, huh.NewOption(strategy.Description(), strategy.Name()))
+	}
+
+	var exclusionOptions []huh.Option[utils.ExclusionMode]
+	for _, mode := range utils.ExclusionModes {
+		exclusionOptions = append(exclusionOptions, huh.NewOption(mode.Description(), mode))
+	}
+
+	var solverOptions []huh.Option[utils.Solver]
+	for _, solver := range utils.Solvers {
+		solverOptions = append(solverOptions, huh.NewOption(solver.Description(), solver))
+	}
+
+	fields := []huh.Field{
+		huh.NewSelect[string]().
+			Key("strategy").
+			Title("Split strategy").
+			Options(strategyOptions...),
+		huh.NewSelect[utils.Solver]().
+			Key("solver").
+			Title("Transfer matching").
+			Options(solverOptions...),
+		huh.NewSelect[utils.RemainderPolicy]().
+			Key("remainder").
+			Title("Leftover gold").
+			Description("Who keeps the coins lost when rounding shares down?").
+			Options(policyOptions...),
+	}
+
+	if len(m.playersToRemove) > 0 {
+		fields = append(fields, huh.NewSelect[utils.ExclusionMode]().
+			Key("exclusion").
+			Title("Excluded players").
+			Description("What happens to the loot and supplies of removed players? Refunding supplies first always refunds them.").
+			Options(exclusionOptions...))
+	}
+
+	prices, err := utils.LoadPriceTable()
+	if err != nil {
+		m.notice = fmt.Sprintf("Could not load price table: %v", err)
+	}
+	m.prices = prices
+	if m.prices != nil && len(m.party.LootedItems) > 0 {
+		fields = append(fields, huh.NewConfirm().
+			Key("reprice").
+			Title("Re-price loot?").
+			Description("Value the looted items with your price table instead of the analyzer").
+			Affirmative("Yes").
+			Negative("No"))
+	}
+
+	m.form = huh.NewForm(
+		huh.NewGroup(append(fields,
+			huh.NewConfirm().
+				Key("useWeights").
+				Title("Use custom share weights?").
+				Description("Give players more or less than an equal share (e.g. 1.2 or 0.5)").
+				Affirmative("Yes").
+				Negative("No").
+				Value(useWeights),
+			huh.NewConfirm().
+				Key("useContribution").
+				Title("Split part by contribution?").
+				Description("Share part of the balance by damage and healing instead of equally").
+				Affirmative("Yes").
+				Negative("No").
+				Value(useContribution),
+		)...),
+		huh.NewGroup(
+			huh.NewInput().
+				Key("contribution").
+				Title("Shared by contribution (%)").
+				Description("The rest is split equally").
+				Value(&contribution).
+				Validate(func(s string) error {
+					_, err := utils.ParsePercent(s)
+					return err
+				}),
+			huh.NewInput().
+				Key("healing").
+				Title("Measured by healing (%)").
+				Description("The rest of the contribution is measured by damage").
+				Value(&healing).
+				Validate(func(s string) error {
+					_, err := utils.ParsePercent(s)
+					return err
+				}),
+		).
+			Title("Contribution").
+			WithHideFunc(func() bool { return !*useContribution }),
+		huh.NewGroup(inputs...).
+			Title("Share weights").
+			WithHideFunc(func() bool { return !*useWeights }),
+	).
+		WithWidth(50).
+		WithShowHelp(false).
+		WithShowErrors(false)
+}
+
+// splitOptions reads the options chosen on the split options form
+func (m Model) splitOptions() []utils.SplitOption {
+	opts := []utils.SplitOption{
+		utils.WithWeights(m.shareWeights()),
+		utils.WithDuration(m.party.Duration),
+	}
+	if policy, ok := m.form.Get("remainder").(utils.RemainderPolicy); ok {
+		opts = append(opts, utils.WithRemainderPolicy(policy))
+	}
+	if solver, ok := m.form.Get("solver").(utils.Solver); ok {
+		opts = append(opts, utils.WithSolver(solver))
+	}
+	if strategy, err := utils.ParseSplitStrategy(m.form.GetString("strategy")); err == nil {
+		opts = append(opts, utils.WithStrategy(strategy))
+	}
+	if mode, ok := m.form.Get("exclusion").(utils.ExclusionMode); ok {
+		excluded := utils.FilterExcludedPlayers(m.players, m.playersToRemove)
+		opts = append(opts, utils.WithExcluded(excluded, mode))
+	}
+	if m.form.GetBool("useContribution") {
+		var blend utils.ContributionBlend
+		blend.Contribution, _ = utils.ParsePercent(m.form.GetString("contribution"))
+		blend.Healing, _ = utils.ParsePercent(m.form.GetString("healing"))
+		opts = append(opts, utils.WithContributionBlend(blend))
+	}
+	return opts
+}
+
+// shareWeights reads the weights entered on the split options form
+func (m Model) shareWeights() map[string]float64 {
+	if !m.form.GetBool("useWeights") {
+		return nil
+	}
+
+	weights := make(map[string]float64, len(m.splitPlayers))
+	for _, player := range m.splitPlayers {
+		if w, err := utils.ParseWeight(m.form.GetString("weight:" + player.Name)); err == nil {
+			weights[player.Name] = w
+		}
+	}
+	return weights
+}
+
+func (m *Model) createDebtsForm() {
+	description := m.notice
+	options := []huh.Option[string]{huh.NewOption("Back", "")}
+	if ledger, err := utils.LoadLedger(); err != nil {
+		description = fmt.Sprintf("Could not load ledger: %v", err)
+	} else {
+		if description != "" {
+			description += "\n\n"
+		}
+		description += utils.FormatDebts(ledger)
+		if netted := ledger.NetDebts(); len(netted) < len(ledger.Unpaid()) {
+			label := fmt.Sprintf("Replace unpaid transfers with the %d netted ones", len(netted))
+			options = append(options, huh.NewOption(label, "net"))
+		}
+	}
+
 	m.form = huh.NewForm(
 		huh.NewGroup(
 			huh.NewNote().
-				Description(utils.FormatTransfers(m.split)).
-				Next(true).
-				NextLabel("Copy to clipboard"),
+				Title("Outstanding debts").
+				Description(description),
+			huh.NewSelect[string]().
+				Key("debtAction").
+				Options(options...),
 		),
 	).
 		WithWidth(50).
@@ -156,12 +482,135 @@ func (m *Model) createResultsForm() {
 		WithShowErrors(false)
 }
 
+// netDebts consolidates the unpaid transfers of the ledger
+func (m *Model) netDebts() {
+	ledger, err := utils.LoadLedger()
+	if err == nil {
+		netted := ledger.Net()
+		if err = ledger.Save(); err == nil {
+			m.notice = fmt.Sprintf("Unpaid transfers replaced by %d netted transfers.", len(netted))
+			return
+		}
+	}
+	m.notice = fmt.Sprintf("Could not net debts: %v", err)
+}
+
+// resultsTabs are the tabs of the results screen, switched with left/right
+var resultsTabs = []string{"Split", "Bank", "Loot", "Contribution"}
+
+const (
+	tabSplit = iota
+	tabBank
+	tabLoot
+	tabContribution
+)
+
+func (m *Model) createResultsForm() {
+	m.resultsTab = tabSplit
+	m.bankCursor = 0
+	m.bankNotice = ""
+	var paidOptions []huh.Option[string]
+	var settled []string
+	paid := new([]string)
+	if m.historyID != "" {
+		ledger, err := utils.LoadLedger()
+		if err != nil {
+			m.notice = fmt.Sprintf("Could not load ledger: %v", err)
+		} else {
+			for _, transfer := range ledger.ForHunt(m.historyID) {
+				label := fmt.Sprintf("%s → %s %s", transfer.From, transfer.To, utils.FormatNumber(transfer.Amount))
+				// Netted or replaced transfers can't be ticked off any more
+				if transfer.SettledBy != "" {
+					settled = append(settled, fmt.Sprintf("%s (replaced by %s)", label, transfer.SettledBy))
+					continue
+				}
+				paidOptions = append(paidOptions, huh.NewOption(label, transfer.ID))
+				if transfer.Paid {
+					*paid = append(*paid, transfer.ID)
+				}
+			}
+		}
+	}
+
+	fields := []huh.Field{
+		huh.NewNote().
+			Description(m.resultsDescription()),
+	}
+	if len(settled) > 0 {
+		fields = append(fields, huh.NewNote().
+			Title("Replaced transfers").
+			Description(strings.Join(settled, "\n")))
+	}
+	if len(paidOptions) > 0 {
+		fields = append(fields, huh.NewMultiSelect[string]().
+			Key("paid").
+			Title("Paid transfers").
+			Description("Tick the transfers already sent").
+			Value(paid).
+			Options(paidOptions...))
+	}
+	var actions []huh.Option[string]
+	for _, formatter := range utils.Formatters() {
+		actions = append(actions, huh.NewOption("Copy as "+formatter.Description, "copy:"+formatter.Name))
+	}
+	actions = append(actions,
+		huh.NewOption("Export JSON", "json"),
+		huh.NewOption("Append to CSV spreadsheet", "csv"),
+	)
+	fields = append(fields, huh.NewSelect[string]().
+		Key("resultsAction").
+		Options(actions...))
+
+	m.form = huh.NewForm(huh.NewGroup(fields...)).
+		WithWidth(50).
+		WithShowHelp(false).
+		WithShowErrors(false)
+}
+
+// savePaidTransfers stores the transfers ticked on the results screen
+func (m Model) savePaidTransfers() error {
+	paid, ok := m.form.Get("paid").([]string)
+	if !ok || m.historyID == "" {
+		return nil
+	}
+
+	ledger, err := utils.LoadLedger()
+	if err != nil {
+		return err
+	}
+	for _, transfer := range ledger.ForHunt(m.historyID) {
+		if transfer.SettledBy != "" {
+			continue
+		}
+		if err := ledger.SetPaid(transfer.ID, slices.Contains(paid, transfer.ID)); err != nil {
+			return err
+		}
+	}
+	return ledger.Save()
+}
+
+func (m Model) resultsDescription() string {
+	description := utils.FormatTransfers(m.split)
+	if len(m.warnings) > 0 {
+		description += "\n" + formatWarnings(m.warnings)
+	}
+	if m.notice != "" {
+		description += "\n" + m.notice + "\n"
+	}
+	return description
+}
+
 func (m *Model) createStartOverForm() {
+	description := "Do you want to calculate another loot split?"
+	if m.notice != "" {
+		description = m.notice + "\n\n" + description
+	}
+
 	m.form = huh.NewForm(
 		huh.NewGroup(
 			huh.NewConfirm().
 				Title("Start over?").
-				Description("Do you want to calculate another loot split?").
+				Description(description).
 				Affirmative("Yes").
 				Negative("No, exit"),
 		),
@@ -184,7 +633,10 @@ func min(x, y int) int {
 
 type analyzerLoadedMsg struct {
 	analyzer string
+	session  *utils.HuntingSession
+	party    utils.Party
 	players  []utils.Player
+	warnings []utils.Warning
 	err      error
 }
 
@@ -197,18 +649,138 @@ func loadAnalyzer() tea.Cmd {
 			return analyzerLoadedMsg{err: err}
 		}
 
-		_, players, err := utils.ParseAnalyzer(analyzer)
+		if utils.IsHuntingSession(analyzer) {
+			session, warnings, err := utils.ParseHuntingSession(analyzer)
+			if err != nil {
+				return analyzerLoadedMsg{warnings: warnings, err: err}
+			}
+			return analyzerLoadedMsg{
+				analyzer: analyzer,
+				session:  &session,
+				warnings: warnings,
+			}
+		}
+
+		party, players, warnings, err := utils.ParseAnalyzerWithWarnings(analyzer)
 		if err != nil {
-			return analyzerLoadedMsg{err: err}
+			return analyzerLoadedMsg{warnings: warnings, err: err}
 		}
 
 		return analyzerLoadedMsg{
 			analyzer: analyzer,
+			party:    party,
 			players:  players,
+			warnings: warnings,
 		}
 	}
 }
 
+type historyLoadedMsg struct {
+	entries []utils.HistoryEntry
+	err     error
+}
+
+func loadHistory() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := utils.LoadHistory()
+		return historyLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// saveHistory stores the finished split, keeping the TUI going if it fails
+func (m *Model) saveHistory() {
+	entry, err := utils.SaveHistory(utils.HistoryEntry{
+		Analyzers: m.analyzers,
+		Party:     m.party,
+		Players:   m.players,
+		Excluded:  m.playersToRemove,
+		Split:     m.split,
+	})
+	switch {
+	case errors.Is(err, utils.ErrDuplicateHunt):
+		m.notice = fmt.Sprintf("This %v, so it was not saved again", err)
+	case err != nil:
+		m.notice = fmt.Sprintf("Could not save to history: %v", err)
+		return
+	case entry.Replaces != "":
+		m.notice = fmt.Sprintf("Replaced the earlier split %s of this hunt", entry.Replaces)
+	}
+	m.split = entry.Split
+	m.historyID = entry.ID
+}
+
+// copyResults copies the split to the clipboard with the named formatter
+func (m *Model) copyResults(format string) {
+	var text string
+	formatter, err := utils.ParseFormatter(format)
+	if err == nil {
+		text, err = formatter.Format(m.party, m.split)
+	}
+	if err == nil {
+		err = utils.WriteClipboard(text)
+	}
+	if err != nil {
+		m.notice = fmt.Sprintf("Could not copy to clipboard: %v", err)
+	}
+}
+
+// appendCSV adds the split to the spreadsheet in the data directory
+func (m *Model) appendCSV() {
+	path, err := utils.CSVPath()
+	if err == nil {
+		err = utils.AppendCSV(path, m.historyID, m.party, m.split)
+	}
+	if err != nil {
+		m.notice = fmt.Sprintf("Could not append to CSV: %v", err)
+		return
+	}
+	m.notice = fmt.Sprintf("Appended to %s", path)
+}
+
+// saveSoloSession stores a solo hunting session in the history
+func (m *Model) saveSoloSession(analyzer string) {
+	entry, err := utils.SaveHistory(utils.HistoryEntry{
+		Analyzers: []string{analyzer},
+		Session:   m.soloSession,
+	})
+	if errors.Is(err, utils.ErrDuplicateHunt) {
+		m.notice = fmt.Sprintf("This %v, so it was not saved again", err)
+	} else if err != nil {
+		m.notice = fmt.Sprintf("Could not save to history: %v", err)
+		return
+	}
+	m.historyID = entry.ID
+}
+
+// openHistoryEntry shows a past split on the results screen
+func (m *Model) openHistoryEntry(entry utils.HistoryEntry) {
+	m.analyzers = entry.Analyzers
+	m.party = entry.Party
+	m.players = entry.Players
+	m.playersToRemove = entry.Excluded
+	m.split = entry.Split
+	m.soloSession = entry.Session
+	m.historyID = entry.ID
+}
+
+// reset forgets the current split so a new one can be started
+func (m *Model) reset() {
+	m.playersToRemove = []string{}
+	m.analyzers = nil
+	m.parties = nil
+	m.sessions = nil
+	m.duplicate = false
+	m.party = utils.Party{}
+	m.players = []utils.Player{}
+	m.splitPlayers = []utils.Player{}
+	m.split = utils.GoldSplit{}
+	m.soloSession = nil
+	m.history = nil
+	m.historyID = ""
+	m.notice = ""
+	m.warnings = nil
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -220,20 +792,71 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Interrupt
 		case "esc", "q":
 			return m, tea.Quit
+		case "left", "right":
+			if m.state == stateResults {
+				if msg.String() == "left" {
+					m.resultsTab = (m.resultsTab + len(resultsTabs) - 1) % len(resultsTabs)
+				} else {
+					m.resultsTab = (m.resultsTab + 1) % len(resultsTabs)
+				}
+				return m, nil
+			}
+		}
+		// Only the Split tab shows the form, so keep it from reacting to keys
+		// while another tab is open
+		if m.state == stateResults && m.resultsTab != tabSplit {
+			switch {
+			case m.resultsTab == tabContribution && msg.String() == "s":
+				m.contribution = m.contribution.Next()
+			case m.resultsTab == tabBank:
+				m.updateBank(msg.String())
+			}
+			return m, nil
 		}
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
-	case analyzerLoadedMsg:
+	case historyLoadedMsg:
+		m.history = msg.entries
 		if msg.err != nil {
-			log.Fatal(msg.err)
+			m.notice = fmt.Sprintf("Could not load history: %v", msg.err)
 		}
-		m.analyzer = msg.analyzer
-		m.players = msg.players
-		m.state = statePlayerRemoval
+		m.state = stateHistory
+		m.createHistoryForm()
+		return m, m.form.Init()
+	case analyzerLoadedMsg:
 		m.loading = false
-		m.createPlayerRemovalForm()
+		if msg.err != nil {
+			m.state = stateError
+			m.createErrorForm(msg.err, msg.warnings)
+			return m, m.form.Init()
+		}
+		if msg.session != nil {
+			if len(m.sessions) > 0 {
+				err := errors.New("a solo Hunting Session analyzer can't be added to a party loot split")
+				m.state = stateError
+				m.createErrorForm(err, nil)
+				return m, m.form.Init()
+			}
+			m.soloSession = msg.session
+			m.warnings = msg.warnings
+			m.saveSoloSession(msg.analyzer)
+			m.state = stateSoloSession
+			m.createSoloSessionForm()
+			return m, m.form.Init()
+		}
+		m.duplicate = slices.Contains(m.analyzers, msg.analyzer)
+		if !m.duplicate {
+			m.warnings = append(m.warnings, msg.warnings...)
+			m.analyzers = append(m.analyzers, msg.analyzer)
+			m.parties = append(m.parties, msg.party)
+			m.sessions = append(m.sessions, msg.players)
+			m.party = utils.MergeParties(m.parties...)
+			m.players = utils.MergePlayers(m.sessions...)
+		}
+		m.state = stateAddSession
+		m.createAddSessionForm()
 		return m, m.form.Init()
 	}
 
@@ -249,9 +872,69 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.form.State == huh.StateCompleted {
 		switch m.state {
 		case stateWelcome:
+			switch m.form.GetString("action") {
+			case "history":
+				return m, loadHistory()
+			case "debts":
+				m.state = stateDebts
+				m.createDebtsForm()
+				return m, m.form.Init()
+			}
 			m.state = stateLoading
 			m.loading = true
 			return m, loadAnalyzer()
+		case stateHistory:
+			id := m.form.GetString("entry")
+			for _, entry := range m.history {
+				if entry.ID == id {
+					m.openHistoryEntry(entry)
+					if m.soloSession != nil {
+						m.state = stateSoloSession
+						m.createSoloSessionForm()
+						return m, m.form.Init()
+					}
+					m.state = stateResults
+					m.createResultsForm()
+					return m, m.form.Init()
+				}
+			}
+			m.reset()
+			m.state = stateWelcome
+			m.createWelcomeForm()
+			return m, m.form.Init()
+		case stateDebts:
+			if m.form.GetString("debtAction") == "net" {
+				m.netDebts()
+				m.createDebtsForm()
+				return m, m.form.Init()
+			}
+			m.reset()
+			m.state = stateWelcome
+			m.createWelcomeForm()
+			return m, m.form.Init()
+		case stateError:
+			switch m.form.GetString("errorAction") {
+			case "retry":
+				m.state = stateLoading
+				m.loading = true
+				return m, loadAnalyzer()
+			case "menu":
+				m.reset()
+				m.state = stateWelcome
+				m.createWelcomeForm()
+				return m, m.form.Init()
+			default:
+				return m, tea.Quit
+			}
+		case stateAddSession:
+			if m.form.GetBool("") {
+				m.state = stateLoading
+				m.loading = true
+				return m, loadAnalyzer()
+			}
+			m.state = statePlayerRemoval
+			m.createPlayerRemovalForm()
+			return m, m.form.Init()
 		case statePlayerRemoval:
 			if multiSelectField := m.form.Get(""); multiSelectField != nil {
 				if values, ok := multiSelectField.([]string); ok {
@@ -259,25 +942,55 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-			remainingPlayers := utils.FilterRemainingPlayers(m.players, m.playersToRemove)
-			m.split = utils.CalculateGoldSplit(remainingPlayers)
+			m.splitPlayers = utils.FilterRemainingPlayers(m.players, m.playersToRemove)
+			m.state = stateSplitOptions
+			m.createSplitOptionsForm()
+			return m, m.form.Init()
+		case stateSplitOptions:
+			if m.form.GetBool("reprice") {
+				var warnings []utils.Warning
+				m.party, m.players, warnings = utils.RepriceLoot(m.party, m.players, m.prices)
+				m.warnings = append(m.warnings, warnings...)
+				m.splitPlayers = utils.FilterRemainingPlayers(m.players, m.playersToRemove)
+			}
+			m.split = utils.CalculateGoldSplit(m.splitPlayers, m.splitOptions()...)
+			m.saveHistory()
 			m.state = stateResults
 			m.createResultsForm()
 			return m, m.form.Init()
+		case stateSoloSession:
+			if err := utils.WriteClipboard(utils.FormatHuntingSession(*m.soloSession)); err != nil {
+				m.notice = fmt.Sprintf("Could not copy to clipboard: %v", err)
+			}
+			m.state = stateStartOver
+			m.createStartOverForm()
+			return m, m.form.Init()
 		case stateResults:
-			utils.SaveToClipboard(m.split)
+			if err := m.savePaidTransfers(); err != nil {
+				m.notice = fmt.Sprintf("Could not save paid transfers: %v", err)
+			}
+			switch m.form.GetString("resultsAction") {
+			case "json":
+				path, err := utils.SaveExport(m.historyID, m.party, m.players, m.split)
+				if err != nil {
+					m.notice = fmt.Sprintf("Could not export JSON: %v", err)
+				} else {
+					m.notice = fmt.Sprintf("Exported to %s", path)
+				}
+			case "csv":
+				m.appendCSV()
+			default:
+				m.copyResults(strings.TrimPrefix(m.form.GetString("resultsAction"), "copy:"))
+			}
 			m.state = stateStartOver
 			m.createStartOverForm()
 			return m, m.form.Init()
 		case stateStartOver:
 			if m.form.GetBool("") {
-				m.playersToRemove = []string{}
-				m.analyzer = ""
-				m.players = []utils.Player{}
-				m.split = utils.GoldSplit{}
-				m.state = stateLoading
-				m.loading = true
-				return m, loadAnalyzer()
+				m.reset()
+				m.state = stateWelcome
+				m.createWelcomeForm()
+				return m, m.form.Init()
 			} else {
 				return m, tea.Quit
 			}
@@ -306,8 +1019,20 @@ func (m Model) View() string {
 			switch m.state {
 			case stateWelcome:
 				headerText = "T-HUB - Loot Split Calculator"
+			case stateHistory:
+				headerText = "T-HUB - History"
+			case stateDebts:
+				headerText = "T-HUB - Debts"
+			case stateError:
+				headerText = "T-HUB - Error"
+			case stateSoloSession:
+				headerText = "T-HUB - Solo Session"
+			case stateAddSession:
+				headerText = "T-HUB - Hunt Sessions"
 			case statePlayerRemoval:
 				headerText = "T-HUB - Player Removal"
+			case stateSplitOptions:
+				headerText = "T-HUB - Split Options"
 			case stateResults:
 				headerText = "T-HUB - Results"
 			case stateStartOver:
@@ -316,6 +1041,18 @@ func (m Model) View() string {
 				headerText = "T-HUB - Loot Split Calculator"
 			}
 			footerText = m.form.Help().ShortHelpView(m.form.KeyBinds())
+			if m.state == stateResults {
+				switch m.resultsTab {
+				case tabSplit:
+					footerText = "←/→ switch tab • " + footerText
+				case tabBank:
+					footerText = m.styles.Help.Render("←/→ switch tab • ↑/↓ select • enter copy and go to next")
+				case tabContribution:
+					footerText = m.styles.Help.Render("←/→ switch tab • s sort")
+				default:
+					footerText = m.styles.Help.Render("←/→ switch tab")
+				}
+			}
 		}
 	}
 
@@ -346,6 +1083,9 @@ func (m Model) View() string {
 	} else {
 		// Form (centered)
 		v := strings.TrimSuffix(m.form.View(), "\n\n")
+		if m.state == stateResults {
+			v = m.resultsTabView(v)
+		}
 		form := s.Status.
 			Width(60).
 			Padding(2).
@@ -360,6 +1100,82 @@ func (m Model) View() string {
 	)
 }
 
+// resultsTabView renders the results tab bar above the active tab
+func (m Model) resultsTabView(form string) string {
+	s := m.styles
+
+	var tabs []string
+	for i, tab := range resultsTabs {
+		if i == m.resultsTab {
+			tabs = append(tabs, s.Highlight.Render("["+tab+"]"))
+		} else {
+			tabs = append(tabs, " "+tab+" ")
+		}
+	}
+	bar := strings.Join(tabs, " ")
+
+	var content string
+	switch m.resultsTab {
+	case tabBank:
+		content = m.bankView()
+	case tabLoot:
+		content = utils.FormatItemLists(m.party.KilledMonsters, m.party.LootedItems)
+	case tabContribution:
+		content = utils.FormatContributions(m.players, m.contribution)
+	default:
+		return bar + "\n\n" + form
+	}
+	return bar + "\n\n" + strings.TrimSuffix(content, "\n")
+}
+
+// updateBank moves the cursor of the Bank tab, or copies the bank command of
+// the selected transfer and moves on to the next one
+func (m *Model) updateBank(key string) {
+	transfers := m.split.DirectTransfers
+	if len(transfers) == 0 {
+		return
+	}
+
+	switch key {
+	case "up", "k":
+		m.bankCursor = (m.bankCursor + len(transfers) - 1) % len(transfers)
+	case "down", "j", "tab":
+		m.bankCursor = (m.bankCursor + 1) % len(transfers)
+	case "enter", "c":
+		transfer := transfers[m.bankCursor]
+		if err := utils.WriteClipboard(transfer.BankCommand()); err != nil {
+			m.bankNotice = fmt.Sprintf("Could not copy to clipboard: %v", err)
+			return
+		}
+		m.bankNotice = fmt.Sprintf("Copied for %s: %s", transfer.From, transfer.BankCommand())
+		m.bankCursor = (m.bankCursor + 1) % len(transfers)
+	}
+}
+
+// bankView lists the transfers of the split with their bank commands
+func (m Model) bankView() string {
+	transfers := m.split.DirectTransfers
+	if len(transfers) == 0 {
+		return "Nobody needs to pay anyone."
+	}
+
+	var sb strings.Builder
+	for i, transfer := range transfers {
+		cursor := "  "
+		line := fmt.Sprintf("%s: %s", transfer.From, transfer.BankCommand())
+		if i == m.bankCursor {
+			cursor = "> "
+			line = m.styles.Highlight.Render(line)
+		}
+		sb.WriteString(cursor + line + "\n")
+	}
+	fmt.Fprintf(&sb, "\n%d/%d", m.bankCursor+1, len(transfers))
+	if m.bankNotice != "" {
+		sb.WriteString("\n" + m.bankNotice)
+	}
+	return sb.String()
+}
+
 func (m Model) errorView() string {
 	var s string
 	for _, err := range m.form.Errors() {
@@ -389,6 +1205,14 @@ func (m Model) appErrorBoundaryView(text string) string {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "t-hub:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	_, err := tea.NewProgram(NewModel(), tea.WithAltScreen()).Run()
 	if err != nil {
 		fmt.Println("Oh no:", err)
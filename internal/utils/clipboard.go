@@ -2,30 +2,29 @@ package utils
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/atotto/clipboard"
 )
 
-func formatFromClipboard(split GoldSplit) string {
-	var sb strings.Builder
-
-	sb.WriteString("=== LOOT SPLIT RESULTS ===\n\n")
+// FormatPlainText renders the split with the built-in DefaultTemplate, the
+// plain text shared on the clipboard
+func FormatPlainText(party Party, split GoldSplit) (string, error) {
+	return RenderTemplate(defaultTemplate, party, split)
+}
 
-	for _, transfer := range split.DirectTransfers {
-		fmt.Fprintf(&sb, "%s to pay %s %s   |   bank: transfer %d to %s\n\n",
-			transfer.From, transfer.To, FormatNumber(transfer.Amount), transfer.Amount, transfer.To)
+// SaveToClipboard copies the split rendered with the user's clipboard
+// template, or the default layout when they have none
+func SaveToClipboard(party Party, split GoldSplit) error {
+	formatted, err := RenderClipboard(party, split)
+	if err != nil {
+		return err
 	}
-
-	fmt.Fprintf(&sb, "\ntotal profit: %s \n", FormatNumber(split.TotalBalance))
-	fmt.Fprintf(&sb, "total for each player: %s \n", FormatNumber(split.EqualShare))
-
-	return sb.String()
+	return clipboard.WriteAll(formatted)
 }
 
-func SaveToClipboard(split GoldSplit) error {
-	formatted := formatFromClipboard(split)
-	return clipboard.WriteAll(formatted)
+// WriteClipboard copies any text to the clipboard
+func WriteClipboard(text string) error {
+	return clipboard.WriteAll(text)
 }
 
 func CopyFromClipboard() (string, error) {
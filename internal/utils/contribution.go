@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Contribution is what a player brought to the hunt compared to the party
+type Contribution struct {
+	Name     string
+	Damage   int
+	Healing  int
+	Loot     int
+	Supplies int
+	// DamageShare and HealingShare are percentages of the party totals
+	DamageShare  float64
+	HealingShare float64
+	// SupplyRatio is supplies spent per gold looted, +Inf when the player
+	// spent supplies but looted nothing
+	SupplyRatio float64
+}
+
+// ContributionSort is the column the contribution report is sorted by
+type ContributionSort int
+
+const (
+	SortByDamage ContributionSort = iota
+	SortByHealing
+	SortBySupplyRatio
+	SortByName
+)
+
+var ContributionSorts = []ContributionSort{
+	SortByDamage,
+	SortByHealing,
+	SortBySupplyRatio,
+	SortByName,
+}
+
+func (s ContributionSort) String() string {
+	switch s {
+	case SortByDamage:
+		return "damage"
+	case SortByHealing:
+		return "healing"
+	case SortBySupplyRatio:
+		return "supplies/loot"
+	case SortByName:
+		return "name"
+	default:
+		return fmt.Sprintf("ContributionSort(%d)", int(s))
+	}
+}
+
+// Next returns the sort column that follows s, wrapping around
+func (s ContributionSort) Next() ContributionSort {
+	return ContributionSorts[(int(s)+1)%len(ContributionSorts)]
+}
+
+// CalculateContributions works out each player's share of the party damage
+// and healing and how much supplies they burned for the loot they got
+func CalculateContributions(players []Player) []Contribution {
+	var damage, healing int
+	for _, player := range players {
+		damage += player.Damage
+		healing += player.Healing
+	}
+
+	contributions := make([]Contribution, len(players))
+	for i, player := range players {
+		c := Contribution{
+			Name:     player.Name,
+			Damage:   player.Damage,
+			Healing:  player.Healing,
+			Loot:     player.Loot,
+			Supplies: player.Supplies,
+		}
+		if damage > 0 {
+			c.DamageShare = float64(player.Damage) * 100 / float64(damage)
+		}
+		if healing > 0 {
+			c.HealingShare = float64(player.Healing) * 100 / float64(healing)
+		}
+		switch {
+		case player.Loot > 0:
+			c.SupplyRatio = float64(player.Supplies) / float64(player.Loot)
+		case player.Supplies > 0:
+			c.SupplyRatio = math.Inf(1)
+		}
+		contributions[i] = c
+	}
+	return contributions
+}
+
+// SortContributions orders the report by the given column: biggest damage
+// and healing first, worst supplies to loot ratio first, or by name
+func SortContributions(contributions []Contribution, by ContributionSort) {
+	sort.SliceStable(contributions, func(i, j int) bool {
+		a, b := contributions[i], contributions[j]
+		switch by {
+		case SortByHealing:
+			return a.Healing > b.Healing
+		case SortBySupplyRatio:
+			return a.SupplyRatio > b.SupplyRatio
+		case SortByName:
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		default:
+			return a.Damage > b.Damage
+		}
+	})
+}
+
+// FormatContributions renders the contribution report as a table sorted by
+// the given column
+func FormatContributions(players []Player, by ContributionSort) string {
+	if len(players) == 0 {
+		return "No players in this hunt.\n"
+	}
+
+	contributions := CalculateContributions(players)
+	SortContributions(contributions, by)
+
+	width := len("player")
+	for _, c := range contributions {
+		width = max(width, utf8.RuneCountInString(c.Name))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-*s  %7s  %7s  %13s\n", width, "player", "damage", "healing", "supplies/loot")
+	for _, c := range contributions {
+		ratio := "-"
+		switch {
+		case math.IsInf(c.SupplyRatio, 1):
+			ratio = "∞"
+		case c.Loot > 0:
+			ratio = fmt.Sprintf("%.2f", c.SupplyRatio)
+		}
+		fmt.Fprintf(&sb, "%-*s  %6.1f%%  %6.1f%%  %13s\n", width, c.Name, c.DamageShare, c.HealingShare, ratio)
+	}
+	fmt.Fprintf(&sb, "\nsorted by %s\n", by)
+	return sb.String()
+}
+
+// ContributionBlend makes part of the split proportional to what each player
+// contributed instead of equal
+type ContributionBlend struct {
+	// Contribution is the fraction of the balance shared by contribution,
+	// from 0 (an equal split) to 1
+	Contribution float64
+	// Healing is the fraction of the contribution measured by healing, the
+	// rest is measured by damage
+	Healing float64
+}
+
+// WithContributionBlend shares part of the balance by damage and healing
+// contribution. Each player's share weight is scaled by their contribution,
+// so custom weights from WithWeights still apply on top.
+func WithContributionBlend(blend ContributionBlend) SplitOption {
+	return func(c *splitConfig) {
+		c.contribution = blend
+	}
+}
+
+func (b ContributionBlend) Enabled() bool {
+	return b.Contribution > 0
+}
+
+func (b ContributionBlend) String() string {
+	return fmt.Sprintf("%.0f%% equal, %.0f%% by contribution (%.0f%% damage, %.0f%% healing)",
+		(1-b.Contribution)*100, b.Contribution*100, (1-b.Healing)*100, b.Healing*100)
+}
+
+// factors returns the multiplier of each player's share. The factors average
+// 1, so a player who contributed exactly their part keeps a single share.
+// When nobody dealt damage or healed, that part counts as equal.
+func (b ContributionBlend) factors(players []Player) []float64 {
+	var damage, healing int
+	for _, player := range players {
+		damage += player.Damage
+		healing += player.Healing
+	}
+
+	n := float64(len(players))
+	fraction := func(value, total int) float64 {
+		if total <= 0 {
+			return 1 / n
+		}
+		return float64(value) / float64(total)
+	}
+
+	factors := make([]float64, len(players))
+	for i, player := range players {
+		contribution := (1-b.Healing)*fraction(player.Damage, damage) + b.Healing*fraction(player.Healing, healing)
+		factor := (1 - b.Contribution) + b.Contribution*n*contribution
+		// Keep the share readable and in the thousandths the split uses
+		factors[i] = math.Round(factor*1000) / 1000
+	}
+	return factors
+}
+
+// ParsePercent parses a percentage from 0 to 100 such as "30" or "30%" and
+// returns it as a fraction
+func ParsePercent(s string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || math.IsNaN(p) || p < 0 || p > 100 {
+		return 0, fmt.Errorf("percentage must be between 0 and 100, got %q", s)
+	}
+	return p / 100, nil
+}
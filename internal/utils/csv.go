@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// csvHeader are the columns of the CSV export: the session metadata and
+// per-hour metrics repeated on every row, followed by the results of one
+// player. The metrics are empty when the hunt duration is unknown.
+var csvHeader = []string{
+	"hunt_id", "session_start", "session_end", "session", "duration_minutes", "loot_type",
+	"party_loot", "party_supplies", "party_balance",
+	"profit_per_hour", "profit_per_hour_per_player", "damage_per_hour", "healing_per_hour",
+	"name", "leader", "excluded", "loot", "supplies", "balance", "damage", "healing",
+	"share", "transfer_amount", "status", "final_balance",
+}
+
+// CSVRows renders a split as CSV rows, one per player transfer, without the
+// header
+func CSVRows(huntID string, party Party, split GoldSplit) [][]string {
+	var start, end string
+	if !party.Start.IsZero() {
+		start = party.Start.Format(time.DateTime)
+		end = party.End.Format(time.DateTime)
+	}
+	session := []string{
+		huntID, start, end, party.Session,
+		strconv.Itoa(int(party.Duration / time.Minute)),
+		party.LootType,
+		strconv.Itoa(party.Loot),
+		strconv.Itoa(party.Supplies),
+		strconv.Itoa(party.Balance),
+	}
+	if metrics, ok := split.Metrics(); ok {
+		session = append(session,
+			strconv.Itoa(metrics.ProfitPerHour),
+			strconv.Itoa(metrics.ProfitPerHourPerPlayer),
+			strconv.Itoa(metrics.DamagePerHour),
+			strconv.Itoa(metrics.HealingPerHour))
+	} else {
+		session = append(session, "", "", "", "")
+	}
+
+	var rows [][]string
+	for _, pt := range split.PlayerTransfers {
+		rows = append(rows, append(slices.Clone(session),
+			pt.Name,
+			strconv.FormatBool(pt.Leader),
+			strconv.FormatBool(pt.Excluded),
+			strconv.Itoa(pt.Loot),
+			strconv.Itoa(pt.Supplies),
+			strconv.Itoa(pt.Balance),
+			strconv.Itoa(pt.Damage),
+			strconv.Itoa(pt.Healing),
+			strconv.FormatFloat(pt.Share, 'f', -1, 64),
+			strconv.Itoa(pt.TransferAmount),
+			pt.Status,
+			strconv.Itoa(pt.FinalBalance),
+		))
+	}
+	return rows
+}
+
+// AppendCSV appends the rows of a split to the CSV file at path, writing the
+// header first when the file is new or empty, so every hunt can land in the
+// same spreadsheet. Files with a different header are left untouched.
+func AppendCSV(path, huntID string, party Party, split GoldSplit) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file: %v", err)
+	}
+	defer f.Close()
+
+	header, err := csv.NewReader(f).Read()
+	switch {
+	case errors.Is(err, io.EOF):
+		header = nil
+	case err != nil:
+		return fmt.Errorf("failed to read CSV header of %s: %v", filepath.Base(path), err)
+	case !slices.Equal(header, csvHeader):
+		return fmt.Errorf("%s has different columns than the T-Hub export", filepath.Base(path))
+	}
+
+	// Spreadsheets don't always end the last row with a newline
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to append to CSV file: %v", err)
+	}
+	if size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			return fmt.Errorf("failed to read CSV file: %v", err)
+		}
+		if last[0] != '\n' {
+			if _, err := f.WriteString("\n"); err != nil {
+				return fmt.Errorf("failed to append to CSV file: %v", err)
+			}
+		}
+	}
+
+	w := csv.NewWriter(f)
+	if header == nil {
+		w.Write(csvHeader)
+	}
+	w.WriteAll(CSVRows(huntID, party, split))
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %v", err)
+	}
+	return nil
+}
+
+// CSVPath is the spreadsheet the TUI appends to, hunts.csv in the data directory
+func CSVPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hunts.csv"), nil
+}
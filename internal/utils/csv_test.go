@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAppendCSV(t *testing.T) {
+	party := Party{LootType: "Market", Loot: 400, Supplies: 100, Balance: 300}
+	split := CalculateGoldSplit([]Player{
+		{Name: "Knight", Leader: true, Loot: 300, Supplies: 100, Balance: 200},
+		{Name: "Druid", Loot: 100, Balance: 100},
+	})
+	header := strings.Join(csvHeader, ",") + "\n"
+
+	tests := []struct {
+		name     string
+		existing *string
+		appends  int
+		wantRows int
+		wantErr  bool
+	}{
+		{name: "new file", appends: 2, wantRows: 4},
+		{name: "empty file", existing: ptr(""), appends: 1, wantRows: 2},
+		{name: "no trailing newline", existing: ptr(strings.TrimSuffix(header, "\n")), appends: 1, wantRows: 2},
+		{name: "different header", existing: ptr("date,gold\n2024-01-15,100"), appends: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hunts.csv")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, []byte(*tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := range tt.appends {
+				err := AppendCSV(path, "hunt", party, split)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("AppendCSV() #%d error = %v, wantErr %v", i+1, err, tt.wantErr)
+				}
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr {
+				if string(data) != *tt.existing {
+					t.Errorf("AppendCSV() changed a file it refused to:\n%s", data)
+				}
+				return
+			}
+
+			records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+			if err != nil {
+				t.Fatalf("AppendCSV() wrote invalid CSV: %v\n%s", err, data)
+			}
+			if !slices.Equal(records[0], csvHeader) {
+				t.Errorf("first row = %q, want the header", records[0])
+			}
+			if got := len(records) - 1; got != tt.wantRows {
+				t.Errorf("%d rows after the header, want %d", got, tt.wantRows)
+			}
+			for _, record := range records[1:] {
+				if slices.Equal(record, csvHeader) {
+					t.Error("AppendCSV() wrote the header again")
+				}
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoPlayers is wrapped by the ParseError returned when the analyzer has no
+// player sections
+var ErrNoPlayers = errors.New("no players found on party analyzer")
+
+// ParseError reports which part of the analyzer could not be parsed
+type ParseError struct {
+	// Section is "party" for the header or the name of the player
+	Section string
+	Field   string
+	// Offset is the byte offset of the bad value in the analyzer, or -1
+	Offset int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %v", location(e.Section, e.Field, e.Offset), e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Warning is a suspicious value that did not stop the analyzer from parsing
+type Warning struct {
+	Section string
+	Field   string
+	Offset  int
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", location(w.Section, w.Field, w.Offset), w.Message)
+}
+
+func location(section, field string, offset int) string {
+	loc := section
+	if field != "" {
+		loc += " " + strings.TrimSuffix(field, ":")
+	}
+	if offset >= 0 {
+		loc += fmt.Sprintf(" (offset %d)", offset)
+	}
+	return loc
+}
+
+// ValidateAnalyzer checks that the parsed totals agree with each other: every
+// player's Loot - Supplies must equal their Balance, and the player sums must
+// match the party header. Mismatches usually mean a truncated clipboard,
+// edited text or player names that were split wrongly.
+func ValidateAnalyzer(party Party, players []Player) []Warning {
+	var warnings []Warning
+	mismatch := func(section, field, format string, args ...any) {
+		warnings = append(warnings, Warning{
+			Section: section,
+			Field:   field,
+			Offset:  -1,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	var loot, supplies, balance int
+	for _, player := range players {
+		if player.Loot-player.Supplies != player.Balance {
+			mismatch(player.Name, "Balance", "loot - supplies is %d but balance is %d",
+				player.Loot-player.Supplies, player.Balance)
+		}
+		loot += player.Loot
+		supplies += player.Supplies
+		balance += player.Balance
+	}
+
+	if party.Loot-party.Supplies != party.Balance {
+		mismatch("party", "Balance", "loot - supplies is %d but balance is %d",
+			party.Loot-party.Supplies, party.Balance)
+	}
+	if loot != party.Loot {
+		mismatch("party", "Loot", "players looted %d but the party total is %d", loot, party.Loot)
+	}
+	if supplies != party.Supplies {
+		mismatch("party", "Supplies", "players used %d supplies but the party total is %d", supplies, party.Supplies)
+	}
+	if balance != party.Balance {
+		mismatch("party", "Balance", "player balances add up to %d but the party balance is %d", balance, party.Balance)
+	}
+
+	return warnings
+}
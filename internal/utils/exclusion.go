@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExclusionMode decides what happens to the loot and supplies of players
+// left out of the split
+type ExclusionMode int
+
+const (
+	// ExcludeIgnore leaves excluded players out of the math entirely
+	ExcludeIgnore ExclusionMode = iota
+	// ExcludeHandOverLoot makes excluded players give their loot to the
+	// party while paying for their own supplies
+	ExcludeHandOverLoot
+	// ExcludeRefundSupplies makes excluded players give their loot to the
+	// party, which refunds their supplies
+	ExcludeRefundSupplies
+)
+
+var ExclusionModes = []ExclusionMode{
+	ExcludeIgnore,
+	ExcludeHandOverLoot,
+	ExcludeRefundSupplies,
+}
+
+func (m ExclusionMode) String() string {
+	switch m {
+	case ExcludeIgnore:
+		return "ignore"
+	case ExcludeHandOverLoot:
+		return "loot"
+	case ExcludeRefundSupplies:
+		return "refund"
+	default:
+		return fmt.Sprintf("ExclusionMode(%d)", int(m))
+	}
+}
+
+// Description says what happens to an excluded player's loot and supplies.
+// The TUI offers it as a choice and the clipboard text repeats it.
+func (m ExclusionMode) Description() string {
+	switch m {
+	case ExcludeIgnore:
+		return "Ignore their loot and supplies"
+	case ExcludeHandOverLoot:
+		return "They hand over their loot"
+	case ExcludeRefundSupplies:
+		return "They hand over their loot and get supplies refunded"
+	default:
+		return m.String()
+	}
+}
+
+// ParseExclusionMode reads the --exclude-mode flag: ignore, loot or refund
+func ParseExclusionMode(s string) (ExclusionMode, error) {
+	for _, m := range ExclusionModes {
+		if strings.EqualFold(s, m.String()) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown exclusion mode %q", s)
+}
+
+// target is the balance an excluded player ends up with
+func (m ExclusionMode) target(player Player) int {
+	switch m {
+	case ExcludeHandOverLoot:
+		return -player.Supplies
+	case ExcludeRefundSupplies:
+		return 0
+	default:
+		return player.Balance
+	}
+}
+
+// WithExcluded settles the players left out of the split with the given
+// mode. Unless the mode ignores them, what they hand over is shared by the
+// players in the split and their transfers are part of the result.
+func WithExcluded(players []Player, mode ExclusionMode) SplitOption {
+	return func(c *splitConfig) {
+		c.excluded = players
+		c.exclusion = mode
+	}
+}
+
+// FilterExcludedPlayers returns the players whose names are in
+// playersToRemove, the counterpart of FilterRemainingPlayers
+func FilterExcludedPlayers(players []Player, playersToRemove []string) []Player {
+	var excluded []Player
+	for _, player := range players {
+		if slices.Contains(playersToRemove, player.Name) {
+			excluded = append(excluded, player)
+		}
+	}
+	return excluded
+}
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ExportVersion is bumped whenever a field of the JSON export changes
+// meaning or is removed. New fields may be added without a bump.
+const ExportVersion = 1
+
+// SplitExport is the stable JSON form of a split for bots and spreadsheets.
+// It mirrors Party, Player and GoldSplit with its own snake_case fields so
+// the internal types can change without breaking consumers.
+type SplitExport struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	HuntID     string           `json:"hunt_id,omitempty"`
+	Party      ExportedParty    `json:"party"`
+	Players    []ExportedPlayer `json:"players"`
+	Split      ExportedSplit    `json:"split"`
+}
+
+type ExportedParty struct {
+	SessionData     string         `json:"session_data"`
+	Session         string         `json:"session"`
+	DurationSeconds int            `json:"duration_seconds"`
+	Start           *time.Time     `json:"start,omitempty"`
+	End             *time.Time     `json:"end,omitempty"`
+	LootType        string         `json:"loot_type"`
+	Loot            int            `json:"loot"`
+	Supplies        int            `json:"supplies"`
+	Balance         int            `json:"balance"`
+	KilledMonsters  []ExportedItem `json:"killed_monsters"`
+	LootedItems     []ExportedItem `json:"looted_items"`
+}
+
+type ExportedItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type ExportedPlayer struct {
+	Name     string `json:"name"`
+	Leader   bool   `json:"leader"`
+	Loot     int    `json:"loot"`
+	Supplies int    `json:"supplies"`
+	Balance  int    `json:"balance"`
+	Damage   int    `json:"damage"`
+	Healing  int    `json:"healing"`
+}
+
+type ExportedSplit struct {
+	TotalBalance    int                      `json:"total_balance"`
+	EqualShare      int                      `json:"equal_share"`
+	Weighted        bool                     `json:"weighted"`
+	Remainder       int                      `json:"remainder"`
+	RemainderPolicy string                   `json:"remainder_policy"`
+	Strategy        string                   `json:"strategy"`
+	ExclusionMode   string                   `json:"exclusion_mode"`
+	Contribution    float64                  `json:"contribution"`
+	HealingWeight   float64                  `json:"healing_weight"`
+	PlayerTransfers []ExportedPlayerTransfer `json:"player_transfers"`
+	DirectTransfers []ExportedTransfer       `json:"direct_transfers"`
+	Summary         ExportedSummary          `json:"summary"`
+	// Metrics is left out when the hunt duration is unknown
+	Metrics *ExportedMetrics `json:"metrics,omitempty"`
+}
+
+type ExportedMetrics struct {
+	ProfitPerHour          int `json:"profit_per_hour"`
+	ProfitPerHourPerPlayer int `json:"profit_per_hour_per_player"`
+	DamagePerHour          int `json:"damage_per_hour"`
+	HealingPerHour         int `json:"healing_per_hour"`
+}
+
+type ExportedPlayerTransfer struct {
+	Name           string  `json:"name"`
+	Excluded       bool    `json:"excluded"`
+	Share          float64 `json:"share"`
+	Remainder      int     `json:"remainder"`
+	TransferAmount int     `json:"transfer_amount"`
+	FinalBalance   int     `json:"final_balance"`
+	Status         string  `json:"status"`
+}
+
+type ExportedTransfer struct {
+	ID     string `json:"id,omitempty"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+type ExportedSummary struct {
+	TotalOwed        int `json:"total_owed"`
+	TotalReceived    int `json:"total_received"`
+	PlayersOwing     int `json:"players_owing"`
+	PlayersReceiving int `json:"players_receiving"`
+	TransferCount    int `json:"transfer_count"`
+}
+
+// NewSplitExport converts a split and the hunt it came from into the export
+// format. huntID is the history ID of the split, if it was saved.
+func NewSplitExport(huntID string, party Party, players []Player, split GoldSplit) SplitExport {
+	export := SplitExport{
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UTC(),
+		HuntID:     huntID,
+		Party: ExportedParty{
+			SessionData:     party.SessionData,
+			Session:         party.Session,
+			DurationSeconds: int(party.Duration / time.Second),
+			LootType:        party.LootType,
+			Loot:            party.Loot,
+			Supplies:        party.Supplies,
+			Balance:         party.Balance,
+			KilledMonsters:  exportItems(party.KilledMonsters),
+			LootedItems:     exportItems(party.LootedItems),
+		},
+		Players: []ExportedPlayer{},
+		Split: ExportedSplit{
+			TotalBalance:    split.TotalBalance,
+			EqualShare:      split.EqualShare,
+			Weighted:        split.Weighted,
+			Remainder:       split.Remainder,
+			RemainderPolicy: split.RemainderPolicy.String(),
+			Strategy:        split.Strategy,
+			ExclusionMode:   split.ExclusionMode.String(),
+			Contribution:    split.Contribution.Contribution,
+			HealingWeight:   split.Contribution.Healing,
+			PlayerTransfers: []ExportedPlayerTransfer{},
+			DirectTransfers: []ExportedTransfer{},
+			Summary: ExportedSummary{
+				TotalOwed:        split.Summary.TotalOwed,
+				TotalReceived:    split.Summary.TotalReceived,
+				PlayersOwing:     split.Summary.PlayersOwing,
+				PlayersReceiving: split.Summary.PlayersReceiving,
+				TransferCount:    split.Summary.TransferCount,
+			},
+		},
+	}
+
+	if !party.Start.IsZero() {
+		start, end := party.Start, party.End
+		export.Party.Start, export.Party.End = &start, &end
+	}
+	if metrics, ok := split.Metrics(); ok {
+		export.Split.Metrics = &ExportedMetrics{
+			ProfitPerHour:          metrics.ProfitPerHour,
+			ProfitPerHourPerPlayer: metrics.ProfitPerHourPerPlayer,
+			DamagePerHour:          metrics.DamagePerHour,
+			HealingPerHour:         metrics.HealingPerHour,
+		}
+	}
+	if export.Split.Strategy == "" {
+		export.Split.Strategy = EqualBalance{}.Name()
+	}
+
+	for _, player := range players {
+		export.Players = append(export.Players, ExportedPlayer{
+			Name:     player.Name,
+			Leader:   player.Leader,
+			Loot:     player.Loot,
+			Supplies: player.Supplies,
+			Balance:  player.Balance,
+			Damage:   player.Damage,
+			Healing:  player.Healing,
+		})
+	}
+	for _, pt := range split.PlayerTransfers {
+		export.Split.PlayerTransfers = append(export.Split.PlayerTransfers, ExportedPlayerTransfer{
+			Name:           pt.Name,
+			Excluded:       pt.Excluded,
+			Share:          pt.Share,
+			Remainder:      pt.Remainder,
+			TransferAmount: pt.TransferAmount,
+			FinalBalance:   pt.FinalBalance,
+			Status:         pt.Status,
+		})
+	}
+	for _, transfer := range split.DirectTransfers {
+		export.Split.DirectTransfers = append(export.Split.DirectTransfers, ExportedTransfer(transfer))
+	}
+	return export
+}
+
+func exportItems(items []ItemCount) []ExportedItem {
+	exported := []ExportedItem{}
+	for _, item := range items {
+		exported = append(exported, ExportedItem(item))
+	}
+	return exported
+}
+
+// ExportJSON renders a split as indented JSON in the export format
+func ExportJSON(huntID string, party Party, players []Player, split GoldSplit) ([]byte, error) {
+	data, err := json.MarshalIndent(NewSplitExport(huntID, party, players, split), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// SaveExport writes the JSON export of a split to the exports directory of
+// the data directory and returns its path. Exports are named after the hunt
+// ID, or the current time when the split wasn't saved to the history.
+func SaveExport(huntID string, party Party, players []Player, split GoldSplit) (string, error) {
+	data, err := ExportJSON(huntID, party, players, split)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "exports")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create exports directory: %v", err)
+	}
+
+	name := huntID
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	path := filepath.Join(dir, name+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to save export: %v", err)
+	}
+	return path, nil
+}
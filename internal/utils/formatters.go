@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formatter renders a split as text to share with the party
+type Formatter struct {
+	// Name is the value --format takes
+	Name string
+	// Description names the format in the "Copy as" results actions
+	Description string
+	Format      func(party Party, split GoldSplit) (string, error)
+}
+
+var formatters = []Formatter{
+	{
+		Name:        "plain",
+		Description: "Plain text",
+		Format:      RenderClipboard,
+	},
+	{
+		Name:        "discord",
+		Description: "Discord",
+		Format: func(_ Party, split GoldSplit) (string, error) {
+			mentions, err := LoadMentions()
+			if err != nil {
+				return "", err
+			}
+			return FormatDiscord(split, mentions), nil
+		},
+	},
+	{
+		Name:        "chat",
+		Description: "In-game chat",
+		Format: func(_ Party, split GoldSplit) (string, error) {
+			return FormatChat(split), nil
+		},
+	},
+}
+
+// Formatters returns a copy of every formatter, plain text first
+func Formatters() []Formatter {
+	return slices.Clone(formatters)
+}
+
+// ParseFormatter finds the formatter with the given name
+func ParseFormatter(name string) (Formatter, error) {
+	for _, f := range formatters {
+		if strings.EqualFold(name, f.Name) {
+			return f, nil
+		}
+	}
+	return Formatter{}, fmt.Errorf("unknown format %q", name)
+}
+
+// LoadMentions reads mentions.json from the data directory, which maps
+// character names to Discord user IDs. It returns nil when there is none or
+// no data directory.
+func LoadMentions() (map[string]string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "mentions.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mentions: %v", err)
+	}
+
+	var mentions map[string]string
+	if err := json.Unmarshal(data, &mentions); err != nil {
+		return nil, fmt.Errorf("failed to decode mentions: %v", err)
+	}
+	return mentions, nil
+}
+
+// FormatDiscord renders the split as Discord markdown: a summary, a table of
+// the transfers in a code block and the bank command of every transfer, with
+// the characters in bold and mentioned when their Discord ID is known
+func FormatDiscord(split GoldSplit, mentions map[string]string) string {
+	var sb strings.Builder
+	name := func(character string) string {
+		if id, ok := mentions[character]; ok {
+			return fmt.Sprintf("**%s** (<@%s>)", character, id)
+		}
+		return "**" + character + "**"
+	}
+
+	fmt.Fprintf(&sb, "**Loot split** · %s: **%s**", split.TotalLabel(), FormatNumber(abs(split.TotalBalance)))
+	if split.HasShare() {
+		fmt.Fprintf(&sb, " · %s: **%s**", split.ShareLabel(), FormatNumber(abs(split.EqualShare)))
+	}
+	sb.WriteString("\n")
+
+	if len(split.DirectTransfers) == 0 {
+		sb.WriteString("Nobody needs to pay anyone.\n")
+		return sb.String()
+	}
+
+	fromWidth, toWidth := len("From"), len("To")
+	for _, transfer := range split.DirectTransfers {
+		fromWidth = max(fromWidth, utf8.RuneCountInString(transfer.From))
+		toWidth = max(toWidth, utf8.RuneCountInString(transfer.To))
+	}
+	sb.WriteString("```\n")
+	fmt.Fprintf(&sb, "%-*s  %-*s  %12s\n", fromWidth, "From", toWidth, "To", "Amount")
+	for _, transfer := range split.DirectTransfers {
+		fmt.Fprintf(&sb, "%-*s  %-*s  %12s\n", fromWidth, transfer.From, toWidth, transfer.To, formatGold(transfer.Amount))
+	}
+	sb.WriteString("```\n")
+
+	for _, transfer := range split.DirectTransfers {
+		fmt.Fprintf(&sb, "%s → %s: `%s`\n", name(transfer.From), name(transfer.To), transfer.BankCommand())
+	}
+	return sb.String()
+}
+
+// FormatChat renders the split as short lines that fit in the in-game chat
+func FormatChat(split GoldSplit) string {
+	var sb strings.Builder
+
+	summary := fmt.Sprintf("%s %s", strings.TrimPrefix(split.TotalLabel(), "total "), FormatNumber(abs(split.TotalBalance)))
+	if split.HasShare() {
+		summary += fmt.Sprintf(", %s each", FormatNumber(abs(split.EqualShare)))
+	}
+	sb.WriteString("Loot split: " + summary + "\n")
+
+	for _, transfer := range split.DirectTransfers {
+		fmt.Fprintf(&sb, "%s -> %s: %d gp\n", transfer.From, transfer.To, transfer.Amount)
+	}
+	return sb.String()
+}
@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryEntry is a completed loot split, or a solo hunting session when
+// Session is set, as stored in the local history
+type HistoryEntry struct {
+	ID        string
+	CreatedAt time.Time
+	Analyzers []string
+	Party     Party
+	Players   []Player
+	Excluded  []string
+	Split     GoldSplit
+	Session   *HuntingSession `json:",omitempty"`
+	// Replaces and ReplacedBy link a split to the one the same hunt was
+	// split again as
+	Replaces   string `json:",omitempty"`
+	ReplacedBy string `json:",omitempty"`
+}
+
+// Title is a one-line summary of the entry used when browsing the history
+func (e HistoryEntry) Title() string {
+	if s := e.Session; s != nil {
+		label := "profit"
+		if s.Balance < 0 {
+			label = "waste"
+		}
+		return fmt.Sprintf("%s · solo · %s %s · %s XP/h",
+			e.CreatedAt.Local().Format("2006-01-02 15:04"),
+			FormatNumber(abs(s.Balance)),
+			label,
+			FormatNumber(s.XPPerHour))
+	}
+
+	title := fmt.Sprintf("%s · %d players · %s %s",
+		e.CreatedAt.Local().Format("2006-01-02 15:04"),
+		len(e.Split.Shareholders()),
+		FormatNumber(abs(e.Split.TotalBalance)),
+		strings.TrimPrefix(e.Split.TotalLabel(), "total "))
+	if e.ReplacedBy != "" {
+		title += " · replaced by " + e.ReplacedBy
+	}
+	return title
+}
+
+// DataDir returns the directory where T-Hub keeps its local data, creating
+// it when needed. It defaults to "t-hub" under the user's config directory
+// and can be moved with the T_HUB_HOME environment variable.
+func DataDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %v", err)
+	}
+	return dir, nil
+}
+
+// dataDir resolves the data directory without creating it
+func dataDir() (string, error) {
+	if dir := os.Getenv("T_HUB_HOME"); dir != "" {
+		return dir, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %v", err)
+	}
+	return filepath.Join(configDir, "t-hub"), nil
+}
+
+func historyDir() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(dir, "history")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %v", err)
+	}
+	return dir, nil
+}
+
+// ErrDuplicateHunt is wrapped by the error SaveHistory returns when the same
+// hunt was already saved with the same split, so it can't put its debts in
+// the ledger twice
+var ErrDuplicateHunt = errors.New("hunt is already in the history")
+
+// huntKey identifies a hunt by its analyzers. Whitespace is ignored so a
+// single-line paste matches the multi-line one, and so is the order of merged
+// analyzers.
+func huntKey(analyzers []string) string {
+	normalized := make([]string, len(analyzers))
+	for i, analyzer := range analyzers {
+		normalized[i] = strings.Join(strings.Fields(analyzer), " ")
+	}
+	slices.Sort(normalized)
+
+	sum := sha256.Sum256([]byte(strings.Join(normalized, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// sameSplit reports whether two splits settle the same way, ignoring the
+// ledger IDs of their transfers
+func sameSplit(a, b GoldSplit) bool {
+	withoutIDs := func(transfers []DirectTransfer) []DirectTransfer {
+		transfers = slices.Clone(transfers)
+		for i := range transfers {
+			transfers[i].ID = ""
+		}
+		return transfers
+	}
+	return reflect.DeepEqual(a.PlayerTransfers, b.PlayerTransfers) &&
+		reflect.DeepEqual(withoutIDs(a.DirectTransfers), withoutIDs(b.DirectTransfers))
+}
+
+// SaveHistory stores a completed split, assigning its ID and creation time,
+// and records its transfers as unpaid in the ledger.
+//
+// Saving a hunt that is already in the history with the same split returns
+// the saved entry and an error wrapping ErrDuplicateHunt. Splitting it
+// differently replaces the saved split: the new entry's Replaces names it and
+// its transfers are settled by the new one, which fails if any of them was
+// already paid or netted.
+func SaveHistory(entry HistoryEntry) (HistoryEntry, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return entry, err
+	}
+
+	var replaced *HistoryEntry
+	if len(entry.Analyzers) > 0 {
+		entries, err := LoadHistory()
+		if err != nil {
+			return entry, err
+		}
+		key := huntKey(entry.Analyzers)
+		for i, saved := range entries {
+			if saved.ReplacedBy != "" || huntKey(saved.Analyzers) != key {
+				continue
+			}
+			if entry.Session != nil || sameSplit(saved.Split, entry.Split) {
+				return saved, fmt.Errorf("%w as %s", ErrDuplicateHunt, saved.ID)
+			}
+			replaced = &entries[i]
+			break
+		}
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	unsaved := entry
+
+	// IDs are timestamps, suffixed when several splits land on the same second
+	base := entry.CreatedAt.Format("20060102-150405")
+	entry.ID = base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, entry.ID+".json")); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		entry.ID = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	transfers := slices.Clone(entry.Split.DirectTransfers)
+	for i := range transfers {
+		transfers[i].ID = fmt.Sprintf("%s.%d", entry.ID, i+1)
+	}
+	entry.Split.DirectTransfers = transfers
+
+	ledger, err := LoadLedger()
+	if err != nil {
+		return entry, err
+	}
+	if replaced != nil {
+		if err := ledger.Replace(replaced.ID, entry.ID); err != nil {
+			return unsaved, err
+		}
+		entry.Replaces = replaced.ID
+		replaced.ReplacedBy = entry.ID
+	}
+
+	if err := writeHistoryEntry(dir, entry); err != nil {
+		return entry, err
+	}
+	if replaced != nil {
+		if err := writeHistoryEntry(dir, *replaced); err != nil {
+			return entry, err
+		}
+	}
+
+	ledger.Record(entry.ID, entry.Split.DirectTransfers)
+	return entry, ledger.Save()
+}
+
+func writeHistoryEntry(dir string, entry HistoryEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, entry.ID+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to save history entry: %v", err)
+	}
+	return nil
+}
+
+// Format renders the entry as the plain text shared on the clipboard
+func (e HistoryEntry) Format() (string, error) {
+	if e.Session != nil {
+		return FormatHuntingSession(*e.Session), nil
+	}
+	return FormatPlainText(e.Party, e.Split)
+}
+
+// LoadHistory returns every stored split, newest first
+func LoadHistory() ([]HistoryEntry, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %v", err)
+	}
+
+	var entries []HistoryEntry
+	for _, file := range files {
+		entry, err := readHistoryEntry(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// LoadHistoryEntry returns the stored split with the given ID
+func LoadHistoryEntry(id string) (HistoryEntry, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return HistoryEntry{}, fmt.Errorf("invalid history id %q", id)
+	}
+
+	entry, err := readHistoryEntry(filepath.Join(dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return HistoryEntry{}, fmt.Errorf("no split with id %q in history", id)
+	}
+	return entry, err
+}
+
+func readHistoryEntry(path string) (HistoryEntry, error) {
+	var entry HistoryEntry
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, fmt.Errorf("failed to read history entry: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("failed to decode history entry %s: %v", filepath.Base(path), err)
+	}
+	return entry, nil
+}
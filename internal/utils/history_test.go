@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSaveHistoryDuplicates(t *testing.T) {
+	t.Setenv("T_HUB_HOME", t.TempDir())
+
+	players := []Player{
+		{Name: "Knight", Leader: true, Loot: 300, Supplies: 100, Balance: 200},
+		{Name: "Druid", Loot: 100, Supplies: 100, Balance: 0},
+		{Name: "Guest", Loot: 100, Balance: 100},
+	}
+	analyzer := "Session data: ...\nLoot: 500"
+	entry := func(split GoldSplit) HistoryEntry {
+		return HistoryEntry{Analyzers: []string{analyzer}, Players: players, Split: split}
+	}
+
+	first, err := SaveHistory(entry(CalculateGoldSplit(players)))
+	if err != nil {
+		t.Fatalf("SaveHistory() error = %v", err)
+	}
+
+	// The same split of the same hunt, pasted on a single line
+	again := entry(CalculateGoldSplit(players))
+	again.Analyzers = []string{"Session data: ... Loot: 500"}
+	saved, err := SaveHistory(again)
+	if !errors.Is(err, ErrDuplicateHunt) {
+		t.Fatalf("SaveHistory() of the same split error = %v, want ErrDuplicateHunt", err)
+	}
+	if saved.ID != first.ID || saved.Split.DirectTransfers[0].ID == "" {
+		t.Errorf("SaveHistory() of the same split returned %q without IDs, want the saved entry %q", saved.ID, first.ID)
+	}
+
+	// A different split of the same hunt replaces the first one
+	second, err := SaveHistory(entry(CalculateGoldSplit(players[:2],
+		WithExcluded(players[2:], ExcludeHandOverLoot))))
+	if err != nil {
+		t.Fatalf("SaveHistory() of another split error = %v", err)
+	}
+	if second.Replaces != first.ID {
+		t.Errorf("Replaces = %q, want %q", second.Replaces, first.ID)
+	}
+
+	ledger, err := LoadLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transfer := range ledger.ForHunt(first.ID) {
+		if transfer.SettledBy != second.ID {
+			t.Errorf("transfer %s of the replaced split is settled by %q, want %q", transfer.ID, transfer.SettledBy, second.ID)
+		}
+	}
+	if got, want := len(ledger.Unpaid()), len(second.Split.DirectTransfers); got != want {
+		t.Errorf("%d unpaid transfers, want the %d of the new split", got, want)
+	}
+
+	old, err := LoadHistoryEntry(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.ReplacedBy != second.ID {
+		t.Errorf("ReplacedBy = %q, want %q", old.ReplacedBy, second.ID)
+	}
+
+	// Once a transfer is paid the hunt can't be split differently any more
+	if err := ledger.SetPaid(second.Split.DirectTransfers[0].ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveHistory(entry(CalculateGoldSplit(players[:1]))); err == nil {
+		t.Error("SaveHistory() replaced a split with paid transfers")
+	}
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LedgerTransfer is a direct transfer generated by a split, tracked until
+// it has been paid
+type LedgerTransfer struct {
+	DirectTransfer
+	HuntID    string
+	CreatedAt time.Time
+	Paid      bool
+	PaidAt    time.Time
+	// SettledBy is the netting batch, or the split of the same hunt, that
+	// replaced this transfer, if any
+	SettledBy string
+}
+
+// Ledger keeps the paid status of every transfer generated by past splits
+type Ledger struct {
+	Transfers []LedgerTransfer
+}
+
+// CharacterDebt is what a character still owes and is owed across all hunts
+type CharacterDebt struct {
+	Name string
+	Owes int
+	Owed int
+}
+
+// Net is positive when the character is owed gold and negative when they owe it
+func (d CharacterDebt) Net() int {
+	return d.Owed - d.Owes
+}
+
+func ledgerPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ledger.json"), nil
+}
+
+// LoadLedger reads the ledger, returning an empty one when nothing was saved yet
+func LoadLedger() (*Ledger, error) {
+	path, err := ledgerPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var ledger Ledger
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ledger, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger: %v", err)
+	}
+	return &ledger, nil
+}
+
+// Save writes the ledger back to the data directory
+func (l *Ledger) Save() error {
+	path, err := ledgerPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ledger: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save ledger: %v", err)
+	}
+	return nil
+}
+
+// NetDebts consolidates the unpaid transfers into the fewest transfers that
+// settle every character's outstanding balance
+func (l *Ledger) NetDebts() []DirectTransfer {
+	var playerTransfers []PlayerTransfer
+	for _, debt := range l.OutstandingDebts() {
+		playerTransfers = append(playerTransfers, PlayerTransfer{
+			Player:         Player{Name: debt.Name},
+			TransferAmount: -debt.Net(),
+		})
+	}
+	return SolverExact.transfers(playerTransfers)
+}
+
+// Net replaces every unpaid transfer with the result of NetDebts. The old
+// transfers are marked as paid and settled by the new batch, whose transfers
+// are recorded as unpaid under a "net-" hunt ID.
+func (l *Ledger) Net() []LedgerTransfer {
+	netted := l.NetDebts()
+	if len(netted) == 0 && len(l.Unpaid()) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	base := "net-" + now.Format("20060102-150405")
+	batchID := base
+	for i := 2; len(l.ForHunt(batchID)) > 0; i++ {
+		batchID = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	for i := range l.Transfers {
+		if !l.Transfers[i].Paid {
+			l.Transfers[i].Paid = true
+			l.Transfers[i].PaidAt = now
+			l.Transfers[i].SettledBy = batchID
+		}
+	}
+
+	for i := range netted {
+		netted[i].ID = fmt.Sprintf("%s.%d", batchID, i+1)
+	}
+	l.Record(batchID, netted)
+	return l.ForHunt(batchID)
+}
+
+// Replace settles the transfers of a hunt that was split again, marking them
+// as replaced by the new split. It changes nothing and fails when one of them
+// was already paid or netted, since the new split doesn't account for it.
+func (l *Ledger) Replace(huntID, byID string) error {
+	for _, transfer := range l.ForHunt(huntID) {
+		switch {
+		case transfer.SettledBy != "":
+			return fmt.Errorf("transfer %q of %s was replaced by %q, so the hunt can't be split again", transfer.ID, huntID, transfer.SettledBy)
+		case transfer.Paid:
+			return fmt.Errorf("transfer %q of %s was already paid, mark it unpaid before splitting the hunt again", transfer.ID, huntID)
+		}
+	}
+
+	now := time.Now()
+	for i := range l.Transfers {
+		if l.Transfers[i].HuntID == huntID {
+			l.Transfers[i].Paid = true
+			l.Transfers[i].PaidAt = now
+			l.Transfers[i].SettledBy = byID
+		}
+	}
+	return nil
+}
+
+// Record adds the transfers of a hunt to the ledger as unpaid
+func (l *Ledger) Record(huntID string, transfers []DirectTransfer) {
+	now := time.Now()
+	for _, transfer := range transfers {
+		l.Transfers = append(l.Transfers, LedgerTransfer{
+			DirectTransfer: transfer,
+			HuntID:         huntID,
+			CreatedAt:      now,
+		})
+	}
+}
+
+// SetPaid marks the transfer with the given ID as paid or unpaid
+func (l *Ledger) SetPaid(id string, paid bool) error {
+	for i := range l.Transfers {
+		if l.Transfers[i].ID != id {
+			continue
+		}
+		if l.Transfers[i].SettledBy != "" {
+			return fmt.Errorf("transfer %q was replaced by %q", id, l.Transfers[i].SettledBy)
+		}
+
+		if paid && !l.Transfers[i].Paid {
+			l.Transfers[i].PaidAt = time.Now()
+		} else if !paid {
+			l.Transfers[i].PaidAt = time.Time{}
+		}
+		l.Transfers[i].Paid = paid
+		return nil
+	}
+	return fmt.Errorf("no transfer with id %q in ledger", id)
+}
+
+// ForHunt returns the transfers generated by a single hunt
+func (l *Ledger) ForHunt(huntID string) []LedgerTransfer {
+	var transfers []LedgerTransfer
+	for _, transfer := range l.Transfers {
+		if transfer.HuntID == huntID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
+
+// Unpaid returns every transfer that has not been paid yet, oldest first
+func (l *Ledger) Unpaid() []LedgerTransfer {
+	var transfers []LedgerTransfer
+	for _, transfer := range l.Transfers {
+		if !transfer.Paid {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers
+}
+
+// OutstandingDebts sums the unpaid transfers per character, biggest debtors first
+func (l *Ledger) OutstandingDebts() []CharacterDebt {
+	var debts []CharacterDebt
+	index := make(map[string]int)
+	debt := func(name string) *CharacterDebt {
+		i, ok := index[name]
+		if !ok {
+			i = len(debts)
+			index[name] = i
+			debts = append(debts, CharacterDebt{Name: name})
+		}
+		return &debts[i]
+	}
+
+	for _, transfer := range l.Unpaid() {
+		debt(transfer.From).Owes += transfer.Amount
+		debt(transfer.To).Owed += transfer.Amount
+	}
+
+	sort.SliceStable(debts, func(i, j int) bool {
+		return debts[i].Net() < debts[j].Net()
+	})
+	return debts
+}
+
+// FormatDebts renders the outstanding transfers and per-character balances
+func FormatDebts(l *Ledger) string {
+	unpaid := l.Unpaid()
+	if len(unpaid) == 0 {
+		return "All transfers are paid.\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== OUTSTANDING TRANSFERS ===\n\n")
+	for _, transfer := range unpaid {
+		fmt.Fprintf(&sb, "[%s] %s to pay %s %s\n",
+			transfer.ID, transfer.From, transfer.To, FormatNumber(transfer.Amount))
+	}
+
+	if netted := l.NetDebts(); len(netted) < len(unpaid) {
+		fmt.Fprintf(&sb, "\n=== NETTED INTO %d TRANSFERS ===\n\n", len(netted))
+		for _, transfer := range netted {
+			fmt.Fprintf(&sb, "%s to pay %s %s   |   bank: %s\n",
+				transfer.From, transfer.To, FormatNumber(transfer.Amount), transfer.BankCommand())
+		}
+	}
+
+	sb.WriteString("\n=== BALANCE PER CHARACTER ===\n\n")
+	for _, debt := range l.OutstandingDebts() {
+		switch {
+		case debt.Net() < 0:
+			fmt.Fprintf(&sb, "%s owes %s\n", debt.Name, FormatNumber(-debt.Net()))
+		case debt.Net() > 0:
+			fmt.Fprintf(&sb, "%s is owed %s\n", debt.Name, FormatNumber(debt.Net()))
+		default:
+			fmt.Fprintf(&sb, "%s is even\n", debt.Name)
+		}
+	}
+	return sb.String()
+}
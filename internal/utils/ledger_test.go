@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ledgerWith records the transfers of a single hunt in a new ledger
+func ledgerWith(huntID string, transfers ...DirectTransfer) *Ledger {
+	ledger := &Ledger{}
+	for i := range transfers {
+		transfers[i].ID = fmt.Sprintf("%s.%d", huntID, i+1)
+	}
+	ledger.Record(huntID, transfers)
+	return ledger
+}
+
+func TestLedgerNetDebts(t *testing.T) {
+	tests := []struct {
+		name      string
+		transfers []DirectTransfer
+		want      []DirectTransfer
+	}{
+		{
+			name: "opposite debts cancel out",
+			transfers: []DirectTransfer{
+				{From: "Knight", To: "Druid", Amount: 100},
+				{From: "Druid", To: "Knight", Amount: 40},
+			},
+			want: []DirectTransfer{{From: "Knight", To: "Druid", Amount: 60}},
+		},
+		{
+			name: "a chain skips the middle character",
+			transfers: []DirectTransfer{
+				{From: "Knight", To: "Druid", Amount: 100},
+				{From: "Druid", To: "Sorcerer", Amount: 100},
+			},
+			want: []DirectTransfer{{From: "Knight", To: "Sorcerer", Amount: 100}},
+		},
+		{
+			name: "everyone even",
+			transfers: []DirectTransfer{
+				{From: "Knight", To: "Druid", Amount: 100},
+				{From: "Druid", To: "Knight", Amount: 100},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ledgerWith("hunt", tt.transfers...).NetDebts()
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NetDebts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLedgerNet(t *testing.T) {
+	ledger := ledgerWith("hunt",
+		DirectTransfer{From: "Knight", To: "Druid", Amount: 100},
+		DirectTransfer{From: "Druid", To: "Knight", Amount: 40})
+	if err := ledger.SetPaid("hunt.2", true); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Record("other", []DirectTransfer{{ID: "other.1", From: "Druid", To: "Knight", Amount: 10}})
+
+	// Batches already taken for the next few seconds force the suffix
+	now := time.Now()
+	for s := range 3 {
+		huntID := "net-" + now.Add(time.Duration(s)*time.Second).Format("20060102-150405")
+		ledger.Transfers = append(ledger.Transfers, LedgerTransfer{HuntID: huntID, Paid: true})
+	}
+
+	batch := ledger.Net()
+	if len(batch) != 1 {
+		t.Fatalf("Net() = %+v, want a single transfer", batch)
+	}
+	batchID := batch[0].HuntID
+	if !strings.HasPrefix(batchID, "net-") || !strings.HasSuffix(batchID, "-2") {
+		t.Errorf("batch ID = %q, want a net- ID with the -2 suffix", batchID)
+	}
+	if got, want := batch[0].DirectTransfer, (DirectTransfer{ID: batchID + ".1", From: "Knight", To: "Druid", Amount: 90}); got != want {
+		t.Errorf("netted transfer = %+v, want %+v", got, want)
+	}
+
+	for _, id := range []string{"hunt.1", "other.1"} {
+		transfer := findTransfer(t, ledger, id)
+		if !transfer.Paid || transfer.SettledBy != batchID {
+			t.Errorf("replaced transfer %s: Paid = %v, SettledBy = %q, want paid and settled by %q",
+				id, transfer.Paid, transfer.SettledBy, batchID)
+		}
+	}
+	if transfer := findTransfer(t, ledger, "hunt.2"); transfer.SettledBy != "" {
+		t.Errorf("transfer paid before netting is settled by %q", transfer.SettledBy)
+	}
+
+	if got := ledger.Net(); len(got) != 0 && got[0].HuntID == batchID {
+		t.Errorf("Net() again reused the batch ID %q", batchID)
+	}
+}
+
+func TestLedgerSetPaid(t *testing.T) {
+	ledger := ledgerWith("hunt", DirectTransfer{From: "Knight", To: "Druid", Amount: 100})
+	ledger.Transfers = append(ledger.Transfers, LedgerTransfer{
+		DirectTransfer: DirectTransfer{ID: "old.1", From: "Knight", To: "Druid", Amount: 50},
+		HuntID:         "old",
+		Paid:           true,
+		SettledBy:      "net-20240115-143021",
+	})
+
+	tests := []struct {
+		name    string
+		id      string
+		paid    bool
+		wantErr bool
+	}{
+		{"pay", "hunt.1", true, false},
+		{"unpay", "hunt.1", false, false},
+		{"unpay a replaced transfer", "old.1", false, true},
+		{"pay a replaced transfer", "old.1", true, true},
+		{"unknown ID", "hunt.2", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ledger.SetPaid(tt.id, tt.paid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetPaid(%q, %v) error = %v, wantErr %v", tt.id, tt.paid, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if transfer := findTransfer(t, ledger, tt.id); transfer.Paid != tt.paid || transfer.PaidAt.IsZero() == tt.paid {
+				t.Errorf("after SetPaid(%q, %v) Paid = %v, PaidAt = %v", tt.id, tt.paid, transfer.Paid, transfer.PaidAt)
+			}
+		})
+	}
+
+	if transfer := findTransfer(t, ledger, "old.1"); !transfer.Paid {
+		t.Error("SetPaid() changed a replaced transfer")
+	}
+}
+
+func findTransfer(t *testing.T, ledger *Ledger, id string) LedgerTransfer {
+	t.Helper()
+
+	for _, transfer := range ledger.Transfers {
+		if transfer.ID == id {
+			return transfer
+		}
+	}
+	t.Fatalf("no transfer %q in the ledger", id)
+	return LedgerTransfer{}
+}
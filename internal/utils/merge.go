@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+)
+
+// MergePlayers combines the players of several hunt sessions into one list,
+// matching them by name and summing their stats. Players who only joined
+// some of the sessions contribute nothing for the others, and a player is
+// marked as leader if they led any of the sessions.
+func MergePlayers(sessions ...[]Player) []Player {
+	var merged []Player
+	index := make(map[string]int)
+
+	for _, players := range sessions {
+		for _, player := range players {
+			i, ok := index[player.Name]
+			if !ok {
+				index[player.Name] = len(merged)
+				merged = append(merged, player)
+				continue
+			}
+
+			merged[i].Leader = merged[i].Leader || player.Leader
+			merged[i].Loot += player.Loot
+			merged[i].Supplies += player.Supplies
+			merged[i].Balance += player.Balance
+			merged[i].Damage += player.Damage
+			merged[i].Healing += player.Healing
+		}
+	}
+
+	return merged
+}
+
+// MergeParties sums the totals of several party hunts into a single party
+func MergeParties(parties ...Party) Party {
+	if len(parties) == 1 {
+		return parties[0]
+	}
+
+	var merged Party
+	var sessionData, sessions []string
+	for i, party := range parties {
+		sessionData = append(sessionData, party.SessionData)
+		sessions = append(sessions, party.Session)
+
+		if i == 0 {
+			merged.LootType = party.LootType
+		} else if merged.LootType != party.LootType {
+			merged.LootType = "Mixed"
+		}
+
+		merged.Loot += party.Loot
+		merged.Supplies += party.Supplies
+		merged.Balance += party.Balance
+		merged.Duration += party.Duration
+		if !party.Start.IsZero() && (merged.Start.IsZero() || party.Start.Before(merged.Start)) {
+			merged.Start = party.Start
+		}
+		if party.End.After(merged.End) {
+			merged.End = party.End
+		}
+		merged.KilledMonsters = mergeItems(merged.KilledMonsters, party.KilledMonsters)
+		merged.LootedItems = mergeItems(merged.LootedItems, party.LootedItems)
+	}
+
+	merged.SessionData = strings.Join(sessionData, "; ")
+	merged.Session = strings.Join(sessions, " + ")
+	return merged
+}
+
+// mergeItems adds the counts of items to list, matching entries by name
+func mergeItems(list, items []ItemCount) []ItemCount {
+	for _, item := range items {
+		i := slices.IndexFunc(list, func(it ItemCount) bool { return it.Name == item.Name })
+		if i == -1 {
+			list = append(list, item)
+		} else {
+			list[i].Count += item.Count
+		}
+	}
+	return list
+}
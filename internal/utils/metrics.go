@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	SessionDurationRX = regexp.MustCompile(`^(\d+):(\d{2})h$`)
+	SessionDataRX     = regexp.MustCompile(`^From (\d{4}-\d{2}-\d{2}, \d{2}:\d{2}:\d{2}) to (\d{4}-\d{2}-\d{2}, \d{2}:\d{2}:\d{2})$`)
+)
+
+// sessionTimeLayout is how the analyzer prints the start and end of a session
+const sessionTimeLayout = "2006-01-02, 15:04:05"
+
+// ParseSessionDuration parses the "Session:" field of an analyzer, such as
+// "01:34h"
+func ParseSessionDuration(s string) (time.Duration, error) {
+	match := SessionDurationRX.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid session duration %q", s)
+	}
+
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	if minutes >= 60 {
+		return 0, fmt.Errorf("invalid session duration %q", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// ParseSessionData parses the "Session data:" field of an analyzer, such as
+// "From 2024-01-15, 14:30:21 to 2024-01-15, 16:05:10". The game prints the
+// times in the player's local time zone.
+func ParseSessionData(s string) (start, end time.Time, err error) {
+	match := SessionDataRX.FindStringSubmatch(s)
+	if match == nil {
+		return start, end, fmt.Errorf("invalid session data %q", s)
+	}
+
+	if start, err = time.ParseInLocation(sessionTimeLayout, match[1], time.Local); err != nil {
+		return start, end, fmt.Errorf("invalid session start %q", match[1])
+	}
+	if end, err = time.ParseInLocation(sessionTimeLayout, match[2], time.Local); err != nil {
+		return start, end, fmt.Errorf("invalid session end %q", match[2])
+	}
+	if end.Before(start) {
+		return start, end, fmt.Errorf("session ends before it starts in %q", s)
+	}
+	return start, end, nil
+}
+
+// FormatDuration renders a duration the way the analyzer does, e.g. "01:34h"
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%02d:%02dh", minutes/60, minutes%60)
+}
+
+// HuntMetrics are the per-hour rates of a split hunt
+type HuntMetrics struct {
+	Duration               time.Duration
+	ProfitPerHour          int
+	ProfitPerHourPerPlayer int
+	DamagePerHour          int
+	HealingPerHour         int
+}
+
+// WithDuration records how long the hunt took so the split can report
+// per-hour metrics
+func WithDuration(d time.Duration) SplitOption {
+	return func(c *splitConfig) {
+		c.duration = d
+	}
+}
+
+// Metrics returns the per-hour rates of the split, or false when the hunt
+// duration is unknown
+func (s GoldSplit) Metrics() (HuntMetrics, bool) {
+	shareholders := s.Shareholders()
+	if s.Duration <= 0 || len(shareholders) == 0 {
+		return HuntMetrics{}, false
+	}
+
+	var damage, healing int
+	for _, pt := range shareholders {
+		damage += pt.Damage
+		healing += pt.Healing
+	}
+
+	profitPerHour := perHour(s.TotalBalance, s.Duration)
+	return HuntMetrics{
+		Duration:               s.Duration,
+		ProfitPerHour:          profitPerHour,
+		ProfitPerHourPerPlayer: perHour(s.TotalBalance, s.Duration*time.Duration(len(shareholders))),
+		DamagePerHour:          perHour(damage, s.Duration),
+		HealingPerHour:         perHour(healing, s.Duration),
+	}, true
+}
+
+func perHour(value int, d time.Duration) int {
+	return int(math.Round(float64(value) * float64(time.Hour) / float64(d)))
+}
+
+// MetricLines are the label and value pairs of the metrics, in display order
+func (m HuntMetrics) MetricLines() [][2]string {
+	profit := "profit"
+	if m.ProfitPerHour < 0 {
+		profit = "waste"
+	}
+	return [][2]string{
+		{"duration", FormatDuration(m.Duration)},
+		{profit + "/h", FormatNumber(abs(m.ProfitPerHour))},
+		{profit + "/h per player", FormatNumber(abs(m.ProfitPerHourPerPlayer))},
+		{"damage/h", FormatNumber(m.DamagePerHour)},
+		{"healing/h", FormatNumber(m.HealingPerHour)},
+	}
+}
@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
 )
@@ -14,9 +17,16 @@ type Party struct {
 	SessionData string
 	Session     string
 	LootType    string
-	Loot        int
-	Supplies    int
-	Balance     int
+	// Duration, Start and End are parsed from Session and SessionData and
+	// are zero when the analyzer didn't have them
+	Duration       time.Duration
+	Start          time.Time
+	End            time.Time
+	Loot           int
+	Supplies       int
+	Balance        int
+	KilledMonsters []ItemCount
+	LootedItems    []ItemCount
 }
 
 type Player struct {
@@ -29,18 +39,20 @@ type Player struct {
 	Healing  int
 }
 
-func parseNumber(s string) int {
-	s = strings.ReplaceAll(s, ",", "")
-	if s == "" {
-		return 0
+func parseNumber(s string) (int, error) {
+	if !AnalyzersValueRX.MatchString(s) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	val, err := strconv.Atoi(strings.ReplaceAll(s, ",", ""))
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
 	}
-	val, _ := strconv.Atoi(s)
-	return val
+	return val, nil
 }
 
 var (
-	LeaderSuffixRX    = regexp.MustCompile(`\s*\(Leader\)\s*$`)
-	AnalyzersNumberRX = regexp.MustCompile(`-?\d[\d,]*`)
+	LeaderSuffixRX   = regexp.MustCompile(`\s*\(Leader\)\s*$`)
+	AnalyzersValueRX = regexp.MustCompile(`^-?\d[\d,]*$`)
 )
 
 func ExtractPlayerNames(players []Player) []huh.Option[string] {
@@ -56,159 +68,339 @@ func ExtractPlayerNames(players []Player) []huh.Option[string] {
 }
 
 func ParseAnalyzer(input string) (Party, []Player, error) {
-	var party Party
-	var players []Player
+	party, players, _, err := ParseAnalyzerWithWarnings(input)
+	return party, players, err
+}
 
-	// Parse party header information
-	party.SessionData = extractValue(input, "Session data:", "Session:")
-	party.Session = extractValue(input, "Session:", "Loot Type:")
-	party.LootType = extractValue(input, "Loot Type:", "Loot:")
-	party.Loot = parseNumber(extractValue(input, "Loot:", "Supplies:"))
-	party.Supplies = parseNumber(extractValue(input, "Supplies:", "Balance:"))
-	party.Balance = parseNumber(extractValue(input, "Balance:", getFirstPlayerName(input)))
+// partyLabels are the field labels of the Party Hunt analyzer
+var partyLabels = []string{
+	"Session data:", "Session:", "Loot Type:",
+	"Loot:", "Supplies:", "Balance:", "Damage:", "Healing:",
+	"Killed Monsters:", "Looted Items:",
+}
 
-	// Extract players
-	playerNames := extractPlayerNames(input)
-	for i, playerName := range playerNames {
-		player := Player{
-			Name:   playerName,
-			Leader: strings.Contains(playerName, "(Leader)"),
-		}
+// ParseAnalyzerWithWarnings parses a Party Hunt analyzer like ParseAnalyzer and
+// also returns the suspicious values it found along the way. Errors are
+// always a *ParseError.
+//
+// The analyzer is tokenized into labels and words and then read with the
+// grammar
+//
+//	analyzer = header { player }
+//	header   = "Session data:" text "Session:" text "Loot Type:" text
+//	           "Loot:" number "Supplies:" number "Balance:" number
+//	player   = name "Loot:" number "Supplies:" number "Balance:" number
+//	           "Damage:" number "Healing:" number
+//	lists    = { "Killed Monsters:" items | "Looted Items:" items }
+//
+// followed by optional lists, where a name is every word between a number
+// and the next label, so names may contain words like "Loot" or be a prefix
+// of another player's name.
+func ParseAnalyzerWithWarnings(input string) (Party, []Player, []Warning, error) {
+	p := &analyzerParser{tokens: tokenize(input, partyLabels), end: len(input)}
+
+	party := p.parseHeader()
+	players := p.parsePlayers()
+	p.parseLists(&party)
+	if p.err != nil {
+		return party, nil, p.warnings, p.err
+	}
 
-		// Clean player name
-		if player.Leader {
-			player.Name = LeaderSuffixRX.ReplaceAllString(player.Name, "")
-		}
+	if len(players) == 0 {
+		return party, nil, p.warnings, &ParseError{Section: "party", Offset: -1, Err: ErrNoPlayers}
+	}
 
-		// Find player data section
-		playerStart := strings.Index(input, playerName)
-		var playerEnd int
-		if i+1 < len(playerNames) {
-			playerEnd = strings.Index(input, playerNames[i+1])
-		} else {
-			playerEnd = len(input)
-		}
+	p.checkPlayers(players)
+	p.warnings = append(p.warnings, ValidateAnalyzer(party, players)...)
+	return party, players, p.warnings, nil
+}
 
-		playerSection := input[playerStart:playerEnd]
+// analyzerParser reads the tokens of an analyzer, keeping the first error
+// and the warnings found along the way
+type analyzerParser struct {
+	tokens []token
+	pos    int
+	// end is the length of the input, the offset reported for missing values
+	end      int
+	warnings []Warning
+	err      error
+}
 
-		// Extract player stats
-		player.Loot = parseNumber(extractPlayerStat(playerSection, "Loot:"))
-		player.Supplies = parseNumber(extractPlayerStat(playerSection, "Supplies:"))
-		player.Balance = parseNumber(extractPlayerStat(playerSection, "Balance:"))
-		player.Damage = parseNumber(extractPlayerStat(playerSection, "Damage:"))
-		player.Healing = parseNumber(extractPlayerStat(playerSection, "Healing:"))
+func (p *analyzerParser) warn(section, field string, offset int, format string, args ...any) {
+	p.warnings = append(p.warnings, Warning{
+		Section: section,
+		Field:   field,
+		Offset:  offset,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
 
-		players = append(players, player)
+func (p *analyzerParser) fail(section, field string, offset int, err error) {
+	if p.err == nil {
+		p.err = &ParseError{Section: section, Field: field, Offset: offset, Err: err}
 	}
+}
 
-	if len(players) == 0 {
-		return party, nil, fmt.Errorf("no players found on party analyzer")
+// next returns the current token without consuming it
+func (p *analyzerParser) next() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{offset: p.end}, false
 	}
+	return p.tokens[p.pos], true
+}
 
-	return party, players, nil
+// at reports whether the given label is the current token
+func (p *analyzerParser) at(label string) bool {
+	t, ok := p.next()
+	return ok && t.kind == tokenLabel && t.text == label
 }
 
-// Helper function to extract value between two markers
-func extractValue(input, startMarker, endMarker string) string {
-	startIdx := strings.Index(input, startMarker)
-	if startIdx == -1 {
-		return ""
+// label consumes the given label if it is the current token
+func (p *analyzerParser) label(label string) bool {
+	if p.at(label) {
+		p.pos++
+		return true
 	}
-	startIdx += len(startMarker)
+	return false
+}
 
-	endIdx := strings.Index(input[startIdx:], endMarker)
-	if endIdx == -1 {
-		return strings.TrimSpace(input[startIdx:])
+// words consumes every word up to the next label
+func (p *analyzerParser) words() []token {
+	var words []token
+	for t, ok := p.next(); ok && t.kind == tokenWord; t, ok = p.next() {
+		words = append(words, t)
+		p.pos++
 	}
-
-	return strings.TrimSpace(input[startIdx : startIdx+endIdx])
+	return words
 }
 
-// Helper function to get the first player name
-func getFirstPlayerName(input string) string {
-	// Find the first occurrence after "Balance:" that doesn't contain ":"
-	balanceIdx := strings.Index(input, "Balance:")
-	if balanceIdx == -1 {
+// text reads a free text header field
+func (p *analyzerParser) text(section, label string) string {
+	if !p.label(label) {
+		p.warn(section, label, -1, "field is missing")
 		return ""
 	}
+	return joinWords(p.words())
+}
 
-	remaining := input[balanceIdx:]
-	// Skip the balance value and find the next non-numeric word
-	words := strings.Fields(remaining)
-	for i, word := range words {
-		if i > 1 && !strings.Contains(word, ":") && !AnalyzersNumberRX.MatchString(word) && !strings.Contains(word, ",") {
-			return word
+// number reads a numeric field, warning when it is missing
+func (p *analyzerParser) number(section, label string) int {
+	if p.err != nil {
+		return 0
+	}
+
+	if !p.label(label) {
+		if t, ok := p.next(); ok && t.kind == tokenWord {
+			p.fail(section, label, t.offset, fmt.Errorf("unexpected %q, expected %s", t.text, label))
+		} else {
+			p.warn(section, label, -1, "field is missing, assuming 0")
 		}
+		return 0
+	}
+
+	t, ok := p.next()
+	if !ok || t.kind != tokenWord {
+		p.fail(section, label, t.offset, errors.New("value is missing"))
+		return 0
 	}
-	return ""
+	p.pos++
+
+	n, err := parseNumber(t.text)
+	if err != nil {
+		p.fail(section, label, t.offset, err)
+	}
+	return n
 }
 
-// Helper function to extract player names from single line format
-func extractPlayerNames(input string) []string {
-	var names []string
+func (p *analyzerParser) parseHeader() Party {
+	var party Party
+
+	if skipped := p.words(); len(skipped) > 0 {
+		p.warn("party", "", skipped[0].offset, "ignored text before the analyzer: %q", joinWords(skipped))
+	}
+
+	party.SessionData = p.text("party", "Session data:")
+	party.Session = p.text("party", "Session:")
+	party.LootType = p.text("party", "Loot Type:")
+	p.parseSessionTimes(&party)
+	party.Loot = p.number("party", "Loot:")
+	party.Supplies = p.number("party", "Supplies:")
+	party.Balance = p.number("party", "Balance:")
+	return party
+}
+
+// parseSessionTimes fills in the duration, start and end of the session.
+// Unrecognised values only warn, since the split doesn't depend on them.
+func (p *analyzerParser) parseSessionTimes(party *Party) {
+	if p.err != nil {
+		return
+	}
+
+	if party.SessionData != "" {
+		start, end, err := ParseSessionData(party.SessionData)
+		if err != nil {
+			p.warn("party", "Session data:", -1, "%v", err)
+		} else {
+			party.Start, party.End = start, end
+		}
+	}
 
-	// Find all potential player names (words that don't contain ":" and appear after balance)
-	balanceIdx := strings.Index(input, "Balance:")
-	if balanceIdx == -1 {
-		return names
+	if party.Session != "" {
+		d, err := ParseSessionDuration(party.Session)
+		if err != nil {
+			p.warn("party", "Session:", -1, "%v", err)
+		} else {
+			party.Duration = d
+		}
+	}
+	if party.Duration == 0 && !party.Start.IsZero() {
+		party.Duration = party.End.Sub(party.Start)
 	}
+}
 
-	remaining := input[balanceIdx:]
-	words := strings.Fields(remaining)
+func (p *analyzerParser) parsePlayers() []Player {
+	var players []Player
 
-	for i := 0; i < len(words); i++ {
-		word := words[i]
-		// Skip numbers, colons, and known keywords
-		if strings.Contains(word, ":") || AnalyzersNumberRX.MatchString(word) ||
-			strings.Contains(word, ",") || word == "Loot" || word == "Supplies" ||
-			word == "Balance" || word == "Damage" || word == "Healing" {
-			continue
+	for p.err == nil {
+		name := p.words()
+		t, ok := p.next()
+		if len(name) == 0 {
+			if ok && !p.at("Killed Monsters:") && !p.at("Looted Items:") {
+				p.fail("party", t.text, t.offset, fmt.Errorf("expected a player name before %s", t.text))
+			}
+			break
+		}
+		if !ok {
+			p.fail(joinWords(name), "", name[0].offset, errors.New("player has no stats"))
+			break
 		}
 
-		// Check if this could be a player name by looking ahead for stats
-		playerName := word
+		player := Player{Name: joinWords(name)}
+		if player.Leader = LeaderSuffixRX.MatchString(player.Name); player.Leader {
+			player.Name = LeaderSuffixRX.ReplaceAllString(player.Name, "")
+		}
 
-		// Handle multi-word names and (Leader) suffix
-		j := i + 1
-		for j < len(words) && !strings.Contains(words[j], ":") {
-			if words[j] == "(Leader)" {
-				playerName += " " + words[j]
-				j++
-				break
-			} else if !AnalyzersNumberRX.MatchString(words[j]) {
-				playerName += " " + words[j]
-				j++
-			} else {
-				break
+		player.Loot = p.number(player.Name, "Loot:")
+		player.Supplies = p.number(player.Name, "Supplies:")
+		player.Balance = p.number(player.Name, "Balance:")
+		player.Damage = p.number(player.Name, "Damage:")
+		player.Healing = p.number(player.Name, "Healing:")
+		players = append(players, player)
+	}
+
+	return players
+}
+
+// parseLists reads the optional killed monsters and looted items lists that
+// may follow the players
+func (p *analyzerParser) parseLists(party *Party) {
+	for p.err == nil {
+		switch {
+		case p.at("Killed Monsters:"):
+			party.KilledMonsters = p.items("party", "Killed Monsters:")
+		case p.at("Looted Items:"):
+			party.LootedItems = p.items("party", "Looted Items:")
+		default:
+			if t, ok := p.next(); ok {
+				p.fail("party", t.text, t.offset, fmt.Errorf("unexpected %q", t.text))
 			}
+			return
 		}
+	}
+}
 
-		// Verify this is a player by checking if "Loot:" follows
-		remainingAfterName := strings.Join(words[j:], " ")
-		if strings.Contains(remainingAfterName, "Loot:") {
-			names = append(names, playerName)
-			i = j - 1 // Skip processed words
+// checkPlayers warns about values that parse but look wrong
+func (p *analyzerParser) checkPlayers(players []Player) {
+	var leaders int
+	seen := make(map[string]bool)
+	for _, player := range players {
+		if seen[player.Name] {
+			p.warn(player.Name, "", -1, "player appears more than once")
+		}
+		seen[player.Name] = true
+
+		if player.Leader {
+			leaders++
+		}
+		if player.Loot < 0 {
+			p.warn(player.Name, "Loot:", -1, "loot is negative")
+		}
+		if player.Supplies < 0 {
+			p.warn(player.Name, "Supplies:", -1, "supplies are negative")
 		}
 	}
 
-	return names
+	switch {
+	case leaders == 0:
+		p.warn("party", "", -1, "no player is marked as leader")
+	case leaders > 1:
+		p.warn("party", "", -1, "%d players are marked as leader", leaders)
+	}
 }
 
-// Helper function to extract player stat value
-func extractPlayerStat(playerSection, statName string) string {
-	statIdx := strings.Index(playerSection, statName)
-	if statIdx == -1 {
-		return "0"
+func joinWords(words []token) string {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.text
 	}
+	return strings.Join(texts, " ")
+}
+
+// FormatAnalyzer renders a party in the multi-line Party Hunt analyzer format
+// of the game. ParseAnalyzer reads the result back into the same values.
+func FormatAnalyzer(party Party, players []Player) string {
+	var sb strings.Builder
 
-	// Find the number after the stat name
-	remaining := playerSection[statIdx+len(statName):]
-	match := AnalyzersNumberRX.FindString(remaining)
-	if match == "" {
-		return "0"
+	fmt.Fprintf(&sb, "Session data: %s\n", party.SessionData)
+	fmt.Fprintf(&sb, "Session: %s\n", party.Session)
+	fmt.Fprintf(&sb, "Loot Type: %s\n", party.LootType)
+	fmt.Fprintf(&sb, "Loot: %s\n", formatGold(party.Loot))
+	fmt.Fprintf(&sb, "Supplies: %s\n", formatGold(party.Supplies))
+	fmt.Fprintf(&sb, "Balance: %s\n", formatGold(party.Balance))
+
+	for _, player := range players {
+		name := player.Name
+		if player.Leader {
+			name += " (Leader)"
+		}
+		fmt.Fprintf(&sb, "%s\n", name)
+		fmt.Fprintf(&sb, "\tLoot: %s\n", formatGold(player.Loot))
+		fmt.Fprintf(&sb, "\tSupplies: %s\n", formatGold(player.Supplies))
+		fmt.Fprintf(&sb, "\tBalance: %s\n", formatGold(player.Balance))
+		fmt.Fprintf(&sb, "\tDamage: %s\n", formatGold(player.Damage))
+		fmt.Fprintf(&sb, "\tHealing: %s\n", formatGold(player.Healing))
 	}
 
-	return match
+	writeItems := func(label string, items []ItemCount) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "%s\n", label)
+		for _, item := range items {
+			fmt.Fprintf(&sb, "\t%dx %s\n", item.Count, item.Name)
+		}
+	}
+	writeItems("Killed Monsters:", party.KilledMonsters)
+	writeItems("Looted Items:", party.LootedItems)
+
+	return sb.String()
+}
+
+// formatGold writes a number with thousands separators like the analyzer does
+func formatGold(i int) string {
+	if i < 0 {
+		return "-" + formatGold(-i)
+	}
+
+	digits := strconv.Itoa(i)
+	var sb strings.Builder
+	for j, d := range digits {
+		if j > 0 && (len(digits)-j)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	return sb.String()
 }
 
 func FilterRemainingPlayers(players []Player, playersToRemove []string) []Player {
@@ -224,9 +416,20 @@ func FilterRemainingPlayers(players []Player, playersToRemove []string) []Player
 	return remainingPlayers
 }
 
+// ParseWeight parses a share weight such as "1.2" or "0,5"
+func ParseWeight(s string) (float64, error) {
+	w, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", 1), 64)
+	if err != nil || w <= 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+		return 0, fmt.Errorf("weight must be a positive number, got %q", s)
+	}
+	return w, nil
+}
+
 // FormatNumber receives a number and transform it into "k" abreviation
 func FormatNumber(i int) string {
 	switch {
+	case i < 0:
+		return "-" + FormatNumber(-i)
 	case i >= 1000000:
 		val := float64(i) / 1000000
 		return fmt.Sprintf("%.2f kk", val)
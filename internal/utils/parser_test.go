@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// singleLine joins an analyzer into one line, the way some chat clients paste it
+func singleLine(analyzer string) string {
+	return strings.Join(strings.Fields(analyzer), " ")
+}
+
+func TestParseAnalyzerNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "name is a prefix of another name",
+			input: `Session data: From 2024-01-15, 14:30:21 to 2024-01-15, 16:05:10
+Session: 01:34h
+Loot Type: Market
+Loot: 300,000
+Supplies: 100,000
+Balance: 200,000
+Knight (Leader)
+	Loot: 100,000
+	Supplies: 50,000
+	Balance: 50,000
+	Damage: 1,000,000
+	Healing: 10,000
+Knight Elite
+	Loot: 200,000
+	Supplies: 50,000
+	Balance: 150,000
+	Damage: 900,000
+	Healing: 20,000
+`,
+			want: []string{"Knight", "Knight Elite"},
+		},
+		{
+			name: "longer name comes first",
+			input: `Session data: From 2024-01-15, 14:30:21 to 2024-01-15, 16:05:10
+Session: 01:34h
+Loot Type: Market
+Loot: 300,000
+Supplies: 100,000
+Balance: 200,000
+Knight Elite (Leader)
+	Loot: 200,000
+	Supplies: 50,000
+	Balance: 150,000
+	Damage: 900,000
+	Healing: 20,000
+Knight
+	Loot: 100,000
+	Supplies: 50,000
+	Balance: 50,000
+	Damage: 1,000,000
+	Healing: 10,000
+`,
+			want: []string{"Knight Elite", "Knight"},
+		},
+		{
+			name: "name contains a field name",
+			input: `Session data: From 2024-01-15, 14:30:21 to 2024-01-15, 16:05:10
+Session: 01:34h
+Loot Type: Leader
+Loot: 300,000
+Supplies: 100,000
+Balance: 200,000
+Druid Loot (Leader)
+	Loot: 100,000
+	Supplies: 50,000
+	Balance: 50,000
+	Damage: 1,000,000
+	Healing: 10,000
+Supplies Balance
+	Loot: 200,000
+	Supplies: 50,000
+	Balance: 150,000
+	Damage: 900,000
+	Healing: 20,000
+`,
+			want: []string{"Druid Loot", "Supplies Balance"},
+		},
+	}
+
+	for _, tt := range tests {
+		for form, input := range map[string]string{"multi-line": tt.input, "single-line": singleLine(tt.input)} {
+			t.Run(tt.name+"/"+form, func(t *testing.T) {
+				_, players, warnings, err := ParseAnalyzerWithWarnings(input)
+				if err != nil {
+					t.Fatalf("ParseAnalyzerWithWarnings() error = %v", err)
+				}
+				if len(warnings) > 0 {
+					t.Errorf("ParseAnalyzerWithWarnings() warnings = %v", warnings)
+				}
+
+				var names []string
+				for _, player := range players {
+					names = append(names, player.Name)
+				}
+				if !reflect.DeepEqual(names, tt.want) {
+					t.Errorf("player names = %q, want %q", names, tt.want)
+				}
+				if !players[0].Leader {
+					t.Errorf("%s is not the leader", players[0].Name)
+				}
+			})
+		}
+	}
+}
+
+// nameWords are the words random names are built from. They include field
+// names and names that are prefixes of each other.
+var nameWords = []string{
+	"Knight", "Elite", "Druid", "Sorcerer", "Paladin", "Loot", "Supplies",
+	"Balance", "Damage", "Healing", "Session", "Type", "Leader", "Killed",
+	"Monsters", "Items", "Looted", "None", "Sir", "of", "Ägil", "Xx",
+}
+
+func randomName(r *rand.Rand) string {
+	words := make([]string, 1+r.Intn(3))
+	for i := range words {
+		words[i] = nameWords[r.Intn(len(nameWords))]
+	}
+	return strings.Join(words, " ")
+}
+
+func randomItems(r *rand.Rand) []ItemCount {
+	var items []ItemCount
+	for range r.Intn(4) {
+		items = append(items, ItemCount{Name: randomName(r), Count: 1 + r.Intn(5000)})
+	}
+	return items
+}
+
+// randomParty builds a consistent party the analyzer could have produced
+func randomParty(r *rand.Rand) (Party, []Player) {
+	start := time.Date(2020+r.Intn(6), time.Month(1+r.Intn(12)), 1+r.Intn(28), 6+r.Intn(12), r.Intn(60), r.Intn(60), 0, time.Local)
+	duration := time.Duration(1+r.Intn(6*60)) * time.Minute
+	end := start.Add(duration + time.Duration(r.Intn(60))*time.Second)
+
+	party := Party{
+		SessionData:    fmt.Sprintf("From %s to %s", start.Format(sessionTimeLayout), end.Format(sessionTimeLayout)),
+		Session:        FormatDuration(duration),
+		LootType:       []string{"Market", "Leader", "Loot Split"}[r.Intn(3)],
+		Duration:       duration,
+		Start:          start,
+		End:            end,
+		KilledMonsters: randomItems(r),
+		LootedItems:    randomItems(r),
+	}
+
+	seen := make(map[string]bool)
+	var players []Player
+	for range 1 + r.Intn(6) {
+		name := randomName(r)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		player := Player{
+			Name:     name,
+			Loot:     r.Intn(5_000_000),
+			Supplies: r.Intn(2_000_000),
+			Damage:   r.Intn(10_000_000),
+			Healing:  r.Intn(3_000_000),
+		}
+		player.Balance = player.Loot - player.Supplies
+		players = append(players, player)
+
+		party.Loot += player.Loot
+		party.Supplies += player.Supplies
+		party.Balance += player.Balance
+	}
+	players[r.Intn(len(players))].Leader = true
+	return party, players
+}
+
+// FuzzParseAnalyzer checks that FormatAnalyzer and ParseAnalyzer round-trip
+// random parties, both as pasted from the game and joined into a single line
+func FuzzParseAnalyzer(f *testing.F) {
+	for seed := range int64(20) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		wantParty, wantPlayers := randomParty(rand.New(rand.NewSource(seed)))
+		analyzer := FormatAnalyzer(wantParty, wantPlayers)
+
+		for _, input := range []string{analyzer, singleLine(analyzer)} {
+			party, players, warnings, err := ParseAnalyzerWithWarnings(input)
+			if err != nil {
+				t.Fatalf("ParseAnalyzerWithWarnings(%q) error = %v", input, err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("ParseAnalyzerWithWarnings(%q) warnings = %v", input, warnings)
+			}
+			if !reflect.DeepEqual(party, wantParty) {
+				t.Errorf("ParseAnalyzerWithWarnings(%q) party = %+v, want %+v", input, party, wantParty)
+			}
+			if !reflect.DeepEqual(players, wantPlayers) {
+				t.Errorf("ParseAnalyzerWithWarnings(%q) players = %+v, want %+v", input, players, wantPlayers)
+			}
+		}
+	})
+}
+
+// FuzzParseAnalyzerInput feeds arbitrary text to the parser, which must never
+// panic and must report every failure as a *ParseError
+func FuzzParseAnalyzerInput(f *testing.F) {
+	party, players := randomParty(rand.New(rand.NewSource(1)))
+	analyzer := FormatAnalyzer(party, players)
+	f.Add(analyzer)
+	f.Add(singleLine(analyzer))
+	f.Add(analyzer[:len(analyzer)/2])
+	f.Add("")
+	f.Add("Session data: Loot: Loot: x\n\tBalance: -")
+	f.Add("Knight (Leader)\n\tLoot: 1,,0\n\tDamage: 99999999999999999999999")
+	f.Add("Killed Monsters: 3x Looted Items: None None")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		_, _, _, err := ParseAnalyzerWithWarnings(input)
+		var parseErr *ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			t.Errorf("ParseAnalyzerWithWarnings(%q) error %v (%T) is not a *ParseError", input, err, err)
+		}
+	})
+}
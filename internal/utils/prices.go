@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ItemPrice is what an item sells for to NPCs and on the market. A zero price
+// means the item can't be sold that way.
+type ItemPrice struct {
+	Name   string `json:"name"`
+	NPC    int    `json:"npc"`
+	Market int    `json:"market"`
+}
+
+// SellPrice is the best price the item can be sold for
+func (p ItemPrice) SellPrice() int {
+	return max(p.NPC, p.Market)
+}
+
+// PriceTable maps item names, as normalized by itemKey, to their prices
+type PriceTable map[string]ItemPrice
+
+// coinValues are the currencies, which never need an entry in the table
+var coinValues = map[string]int{
+	"gold coin":     1,
+	"platinum coin": 100,
+	"crystal coin":  10000,
+}
+
+// itemKey normalizes item names so "a Dragon Shield" matches "dragon shield"
+func itemKey(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	for _, article := range []string{"a ", "an "} {
+		key = strings.TrimPrefix(key, article)
+	}
+	return key
+}
+
+// Lookup returns the price of an item, including the fixed value of coins
+func (t PriceTable) Lookup(name string) (ItemPrice, bool) {
+	key := itemKey(name)
+	if value, ok := coinValues[key]; ok {
+		return ItemPrice{Name: name, NPC: value, Market: value}, true
+	}
+	price, ok := t[key]
+	return price, ok
+}
+
+// LoadPriceTable reads prices.json or, failing that, prices.csv from the data
+// directory. It returns a nil table when neither file exists.
+func LoadPriceTable() (PriceTable, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"prices.json", "prices.csv"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return ReadPriceTable(path)
+	}
+	return nil, nil
+}
+
+// ReadPriceTable reads a price table from a JSON or CSV file. JSON files hold
+// a list of {"name", "npc", "market"} objects and CSV files the columns
+// name,npc,market with an optional header row.
+func ReadPriceTable(path string) (PriceTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price table: %v", err)
+	}
+	defer f.Close()
+
+	var prices []ItemPrice
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		prices, err = readPricesCSV(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&prices)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode price table %s: %v", filepath.Base(path), err)
+	}
+
+	table := make(PriceTable, len(prices))
+	for _, price := range prices {
+		if price.Name == "" {
+			return nil, fmt.Errorf("price table %s has an item without a name", filepath.Base(path))
+		}
+		if price.NPC < 0 || price.Market < 0 {
+			return nil, fmt.Errorf("price table %s has a negative price for %q", filepath.Base(path), price.Name)
+		}
+		table[itemKey(price.Name)] = price
+	}
+	return table, nil
+}
+
+func readPricesCSV(r io.Reader) ([]ItemPrice, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []ItemPrice
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && strings.EqualFold(record[0], "name") {
+			continue
+		}
+		if len(record) != 3 {
+			return nil, fmt.Errorf("line %d: expected name,npc,market", i+1)
+		}
+
+		price := ItemPrice{Name: record[0]}
+		for j, value := range []*int{&price.NPC, &price.Market} {
+			field := strings.ReplaceAll(strings.TrimSpace(record[j+1]), ",", "")
+			if field == "" {
+				continue
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid price %q", i+1, record[j+1])
+			}
+			*value = n
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// analyzerPrice estimates the value the analyzer gave an item. With the
+// "Market" loot type the game values items at their market price, otherwise
+// at what NPCs pay for them.
+func analyzerPrice(price ItemPrice, lootType string) int {
+	if lootType == "Market" && price.Market > 0 {
+		return price.Market
+	}
+	return price.NPC
+}
+
+// RepriceLoot re-values the looted items of the party with the price table,
+// so the loot reflects what the items actually sell for. The analyzer only
+// lists looted items for the whole party, so the difference is shared among
+// the players in proportion to the loot they picked up. Items missing from
+// the table keep the analyzer's value and are reported as warnings.
+func RepriceLoot(party Party, players []Player, prices PriceTable) (Party, []Player, []Warning) {
+	var warnings []Warning
+	var delta int
+	for _, item := range party.LootedItems {
+		price, ok := prices.Lookup(item.Name)
+		if !ok {
+			warnings = append(warnings, Warning{
+				Section: "prices",
+				Field:   item.Name,
+				Offset:  -1,
+				Message: "not in the price table, keeping the analyzer value",
+			})
+			continue
+		}
+		delta += item.Count * (price.SellPrice() - analyzerPrice(price, party.LootType))
+	}
+
+	repriced := append([]Player(nil), players...)
+	if delta == 0 || len(repriced) == 0 {
+		return party, repriced, warnings
+	}
+
+	party.Loot += delta
+	party.Balance += delta
+	shareLoot(repriced, delta)
+	return party, repriced, warnings
+}
+
+// shareLoot adds delta to the loot and balance of the players in proportion
+// to their loot, or equally when none of them looted anything. It hands out
+// the floor of each player's part, then the coins lost to rounding one at a
+// time so the players still add up to the party.
+func shareLoot(players []Player, delta int) {
+	var totalLoot int
+	for _, player := range players {
+		totalLoot += player.Loot
+	}
+
+	given := 0
+	for i, player := range players {
+		var part int
+		if totalLoot > 0 {
+			part = floorDiv(delta*player.Loot, totalLoot)
+		} else {
+			part = floorDiv(delta, len(players))
+		}
+		players[i].Loot += part
+		players[i].Balance += part
+		given += part
+	}
+	for i := 0; given < delta; i = (i + 1) % len(players) {
+		players[i].Loot++
+		players[i].Balance++
+		given++
+	}
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepriceLoot(t *testing.T) {
+	prices := PriceTable{
+		"dragon shield":   {Name: "Dragon Shield", NPC: 4000, Market: 5000},
+		"platinum amulet": {Name: "Platinum Amulet", NPC: 2500},
+	}
+
+	tests := []struct {
+		name         string
+		lootType     string
+		items        []ItemCount
+		loot         []int
+		want         []int
+		wantWarnings int
+	}{
+		{
+			name:     "shared by loot, the first player keeps the rounding",
+			lootType: "Leader",
+			items:    []ItemCount{{Name: "a dragon shield", Count: 1}},
+			loot:     []int{100, 100, 100},
+			want:     []int{434, 433, 433},
+		},
+		{
+			name:     "market prices are already what the items sell for",
+			lootType: "Market",
+			items:    []ItemCount{{Name: "dragon shield", Count: 3}, {Name: "platinum amulet", Count: 2}},
+			loot:     []int{100, 300},
+			want:     []int{100, 300},
+		},
+		{
+			name:         "coins and unknown items keep their value",
+			lootType:     "Leader",
+			items:        []ItemCount{{Name: "gold coin", Count: 500}, {Name: "Demon Horn", Count: 1}},
+			loot:         []int{100, 300},
+			want:         []int{100, 300},
+			wantWarnings: 1,
+		},
+		{
+			name:     "nobody looted anything",
+			lootType: "Leader",
+			items:    []ItemCount{{Name: "dragon shield", Count: 2}},
+			loot:     []int{0, 0, 0},
+			want:     []int{667, 667, 666},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			party := Party{LootType: tt.lootType, LootedItems: tt.items}
+			var players []Player
+			for i, loot := range tt.loot {
+				players = append(players, Player{Name: string(rune('A' + i)), Loot: loot, Supplies: 50, Balance: loot - 50})
+				party.Loot += loot
+				party.Supplies += 50
+				party.Balance += loot - 50
+			}
+
+			party, repriced, warnings := RepriceLoot(party, players, prices)
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+
+			var got []int
+			var loot, balance int
+			for _, player := range repriced {
+				got = append(got, player.Loot)
+				loot += player.Loot
+				balance += player.Balance
+				if player.Balance != player.Loot-player.Supplies {
+					t.Errorf("%s has balance %d, want loot minus supplies %d", player.Name, player.Balance, player.Loot-player.Supplies)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loot = %v, want %v", got, tt.want)
+			}
+			if loot != party.Loot || balance != party.Balance {
+				t.Errorf("players add up to loot %d and balance %d, want the party's %d and %d", loot, balance, party.Loot, party.Balance)
+			}
+			if players[0].Loot != tt.loot[0] {
+				t.Error("RepriceLoot() changed the players it was given")
+			}
+		})
+	}
+}
+
+func TestShareLoot(t *testing.T) {
+	tests := []struct {
+		name  string
+		loot  []int
+		delta int
+		want  []int
+	}{
+		{"proportional", []int{100, 300}, 400, []int{200, 600}},
+		{"rounding goes round-robin", []int{1, 1, 1}, 5, []int{3, 3, 2}},
+		{"negative delta", []int{100, 100, 100}, -100, []int{67, 67, 66}},
+		{"negative delta down to a loss", []int{0, 100}, -300, []int{0, -200}},
+		{"zero total loot", []int{0, 0}, -5, []int{-2, -3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var players []Player
+			for _, loot := range tt.loot {
+				players = append(players, Player{Loot: loot, Balance: loot})
+			}
+
+			shareLoot(players, tt.delta)
+
+			var got []int
+			for _, player := range players {
+				got = append(got, player.Loot)
+				if player.Balance != player.Loot {
+					t.Errorf("balance %d didn't move with the loot %d", player.Balance, player.Loot)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loot = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPricesCSV(t *testing.T) {
+	want := []ItemPrice{
+		{Name: "Dragon Shield", NPC: 4000, Market: 5000},
+		{Name: "Demon Horn", Market: 1200},
+		{Name: "Platinum Amulet", NPC: 2500},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []ItemPrice
+		wantErr bool
+	}{
+		{
+			name:  "header row",
+			input: "name,npc,market\nDragon Shield,4000,5000\nDemon Horn,,1200\nPlatinum Amulet,2500,\n",
+			want:  want,
+		},
+		{
+			name:  "no header row",
+			input: "Dragon Shield,4000,5000\nDemon Horn,,1200\nPlatinum Amulet,2500,\n",
+			want:  want,
+		},
+		{
+			name:  "quoted thousands and spaces",
+			input: "Name, NPC, Market\nDragon Shield, \"4,000\", \"5,000\"\n",
+			want:  want[:1],
+		},
+		{
+			name:    "missing column",
+			input:   "Dragon Shield,4000\n",
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			input:   "Dragon Shield,4k,5000\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPricesCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPricesCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readPricesCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
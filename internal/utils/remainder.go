@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RemainderPolicy decides who keeps the gold left over after every share
+// has been rounded down to a whole coin
+type RemainderPolicy int
+
+const (
+	RemainderToLeader RemainderPolicy = iota
+	RemainderToTopDamage
+	RemainderRoundRobin
+	RemainderToPayer
+)
+
+var RemainderPolicies = []RemainderPolicy{
+	RemainderToLeader,
+	RemainderToTopDamage,
+	RemainderRoundRobin,
+	RemainderToPayer,
+}
+
+func (p RemainderPolicy) String() string {
+	switch p {
+	case RemainderToLeader:
+		return "leader"
+	case RemainderToTopDamage:
+		return "damage"
+	case RemainderRoundRobin:
+		return "round-robin"
+	case RemainderToPayer:
+		return "payer"
+	default:
+		return fmt.Sprintf("RemainderPolicy(%d)", int(p))
+	}
+}
+
+// Description tells the split options screen who keeps the leftover coins
+func (p RemainderPolicy) Description() string {
+	switch p {
+	case RemainderToLeader:
+		return "Give it to the party leader"
+	case RemainderToTopDamage:
+		return "Give it to the top damage dealer"
+	case RemainderRoundRobin:
+		return "Hand it out 1 gp at a time"
+	case RemainderToPayer:
+		return "Keep it with the biggest payer"
+	default:
+		return p.String()
+	}
+}
+
+// ParseRemainderPolicy reads the --remainder flag, such as "round-robin"
+func ParseRemainderPolicy(s string) (RemainderPolicy, error) {
+	for _, p := range RemainderPolicies {
+		if strings.EqualFold(s, p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown remainder policy %q", s)
+}
+
+// distributeRemainder adds the remainder to the targets of the players chosen
+// by the policy and returns how much each player received
+func distributeRemainder(players []Player, targets []int, remainder int, policy RemainderPolicy) []int {
+	extra := make([]int, len(players))
+	if remainder <= 0 || len(players) == 0 {
+		return extra
+	}
+
+	if policy == RemainderRoundRobin {
+		for i := 0; i < remainder; i++ {
+			extra[i%len(players)]++
+		}
+	} else {
+		extra[remainderRecipient(players, targets, policy)] = remainder
+	}
+
+	for i := range targets {
+		targets[i] += extra[i]
+	}
+	return extra
+}
+
+// remainderRecipient returns the index of the single player who keeps the
+// remainder, falling back to the first player
+func remainderRecipient(players []Player, targets []int, policy RemainderPolicy) int {
+	recipient := 0
+	for i, player := range players {
+		switch policy {
+		case RemainderToLeader:
+			if player.Leader {
+				return i
+			}
+		case RemainderToTopDamage:
+			if player.Damage > players[recipient].Damage {
+				recipient = i
+			}
+		case RemainderToPayer:
+			if player.Balance-targets[i] > players[recipient].Balance-targets[recipient] {
+				recipient = i
+			}
+		}
+	}
+	return recipient
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ItemCount is an entry of the killed monsters or looted items lists
+type ItemCount struct {
+	Name  string
+	Count int
+}
+
+// HuntingSession is a parsed solo Hunting Session analyzer
+type HuntingSession struct {
+	SessionData    string
+	Session        string
+	RawXPGain      int
+	XPGain         int
+	RawXPPerHour   int
+	XPPerHour      int
+	Loot           int
+	Supplies       int
+	Balance        int
+	Damage         int
+	DamagePerHour  int
+	Healing        int
+	HealingPerHour int
+	KilledMonsters []ItemCount
+	LootedItems    []ItemCount
+}
+
+// sessionLabels are the field labels of the Hunting Session analyzer
+var sessionLabels = []string{
+	"Session data:", "Session:",
+	"Raw XP Gain:", "XP Gain:", "Raw XP/h:", "XP/h:",
+	"Loot:", "Supplies:", "Balance:",
+	"Damage:", "Damage/h:", "Healing:", "Healing/h:",
+	"Killed Monsters:", "Looted Items:",
+}
+
+var ItemCountRX = regexp.MustCompile(`^(\d[\d,]*)x$`)
+
+// IsHuntingSession reports whether the input looks like a solo Hunting
+// Session analyzer rather than a Party Hunt one
+func IsHuntingSession(input string) bool {
+	for _, t := range tokenize(input, sessionLabels) {
+		if t.kind == tokenLabel && (t.text == "XP Gain:" || t.text == "Raw XP Gain:") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHuntingSession parses a solo Hunting Session analyzer. Errors are
+// always a *ParseError.
+func ParseHuntingSession(input string) (HuntingSession, []Warning, error) {
+	var s HuntingSession
+	p := &analyzerParser{tokens: tokenize(input, sessionLabels), end: len(input)}
+
+	if skipped := p.words(); len(skipped) > 0 {
+		p.warn("session", "", skipped[0].offset, "ignored text before the analyzer: %q", joinWords(skipped))
+	}
+
+	s.SessionData = p.text("session", "Session data:")
+	s.Session = p.text("session", "Session:")
+	s.RawXPGain = p.number("session", "Raw XP Gain:")
+	s.XPGain = p.number("session", "XP Gain:")
+	s.RawXPPerHour = p.number("session", "Raw XP/h:")
+	s.XPPerHour = p.number("session", "XP/h:")
+	s.Loot = p.number("session", "Loot:")
+	s.Supplies = p.number("session", "Supplies:")
+	s.Balance = p.number("session", "Balance:")
+	s.Damage = p.number("session", "Damage:")
+	s.DamagePerHour = p.number("session", "Damage/h:")
+	s.Healing = p.number("session", "Healing:")
+	s.HealingPerHour = p.number("session", "Healing/h:")
+	s.KilledMonsters = p.items("session", "Killed Monsters:")
+	s.LootedItems = p.items("session", "Looted Items:")
+
+	if t, ok := p.next(); ok && p.err == nil {
+		p.fail("session", t.text, t.offset, fmt.Errorf("unexpected %q", t.text))
+	}
+	if p.err != nil {
+		return s, p.warnings, p.err
+	}
+
+	if s.Loot-s.Supplies != s.Balance {
+		p.warn("session", "Balance", -1, "loot - supplies is %d but balance is %d", s.Loot-s.Supplies, s.Balance)
+	}
+	return s, p.warnings, nil
+}
+
+// items reads a list of "<count>x <name>" entries, which the game prints as
+// "None" when the list is empty
+func (p *analyzerParser) items(section, label string) []ItemCount {
+	if p.err != nil {
+		return nil
+	}
+	if !p.label(label) {
+		p.warn(section, label, -1, "list is missing")
+		return nil
+	}
+
+	words := p.words()
+	if len(words) == 1 && words[0].text == "None" {
+		return nil
+	}
+
+	var items []ItemCount
+	for i := 0; i < len(words); {
+		match := ItemCountRX.FindStringSubmatch(words[i].text)
+		if match == nil {
+			p.fail(section, label, words[i].offset, fmt.Errorf("expected an item count, got %q", words[i].text))
+			return nil
+		}
+
+		count, err := parseNumber(match[1])
+		if err != nil {
+			p.fail(section, label, words[i].offset, err)
+			return nil
+		}
+
+		j := i + 1
+		for j < len(words) && !ItemCountRX.MatchString(words[j].text) {
+			j++
+		}
+		if j == i+1 {
+			p.fail(section, label, words[i].offset, errors.New("item name is missing"))
+			return nil
+		}
+
+		items = append(items, ItemCount{Name: joinWords(words[i+1 : j]), Count: count})
+		i = j
+	}
+	return items
+}
+
+// FormatHuntingSession renders a solo hunting session as plain text
+func FormatHuntingSession(s HuntingSession) string {
+	var sb strings.Builder
+
+	sb.WriteString("=== HUNTING SESSION ===\n\n")
+	fmt.Fprintf(&sb, "session: %s\n", s.Session)
+	fmt.Fprintf(&sb, "xp gain: %s (%s/h)\n", FormatNumber(s.XPGain), FormatNumber(s.XPPerHour))
+	fmt.Fprintf(&sb, "raw xp gain: %s (%s/h)\n", FormatNumber(s.RawXPGain), FormatNumber(s.RawXPPerHour))
+	fmt.Fprintf(&sb, "loot: %s\n", FormatNumber(s.Loot))
+	fmt.Fprintf(&sb, "supplies: %s\n", FormatNumber(s.Supplies))
+	if s.Balance < 0 {
+		fmt.Fprintf(&sb, "waste: %s\n", FormatNumber(-s.Balance))
+	} else {
+		fmt.Fprintf(&sb, "profit: %s\n", FormatNumber(s.Balance))
+	}
+	fmt.Fprintf(&sb, "damage: %s (%s/h)\n", FormatNumber(s.Damage), FormatNumber(s.DamagePerHour))
+	fmt.Fprintf(&sb, "healing: %s (%s/h)\n", FormatNumber(s.Healing), FormatNumber(s.HealingPerHour))
+
+	if len(s.KilledMonsters) > 0 || len(s.LootedItems) > 0 {
+		sb.WriteString("\n" + FormatItemLists(s.KilledMonsters, s.LootedItems))
+	}
+
+	return sb.String()
+}
+
+// FormatItemLists renders the killed monsters and looted items lists, most
+// frequent first
+func FormatItemLists(killedMonsters, lootedItems []ItemCount) string {
+	if len(killedMonsters) == 0 && len(lootedItems) == 0 {
+		return "No killed monsters or looted items in this analyzer.\n"
+	}
+
+	var sb strings.Builder
+	writeItems := func(title string, items []ItemCount) {
+		if len(items) == 0 {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		items = slices.Clone(items)
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Count > items[j].Count
+		})
+
+		var total int
+		for _, item := range items {
+			total += item.Count
+		}
+		fmt.Fprintf(&sb, "%s (%d):\n", title, total)
+		for _, item := range items {
+			fmt.Fprintf(&sb, "  %dx %s\n", item.Count, item.Name)
+		}
+	}
+
+	writeItems("killed monsters", killedMonsters)
+	writeItems("looted items", lootedItems)
+	return sb.String()
+}
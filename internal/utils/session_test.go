@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const huntingSession = `Session data: From 2024-01-15, 14:30:21 to 2024-01-15, 16:05:10
+Session: 01:34h
+Raw XP Gain: 1,234,567
+XP Gain: 1,851,850
+Raw XP/h: 788,021
+XP/h: 1,182,031
+Loot: 450,000
+Supplies: 120,000
+Balance: 330,000
+Damage: 3,400,000
+Damage/h: 2,170,212
+Healing: 250,000
+Healing/h: 159,574
+Killed Monsters:
+	1,024x dragon lord
+	87x frost dragon
+Looted Items:
+	2x a dragon shield
+	1,500x gold coin
+	1x a royal helmet
+`
+
+func TestParseHuntingSession(t *testing.T) {
+	want := HuntingSession{
+		SessionData:    "From 2024-01-15, 14:30:21 to 2024-01-15, 16:05:10",
+		Session:        "01:34h",
+		RawXPGain:      1234567,
+		XPGain:         1851850,
+		RawXPPerHour:   788021,
+		XPPerHour:      1182031,
+		Loot:           450000,
+		Supplies:       120000,
+		Balance:        330000,
+		Damage:         3400000,
+		DamagePerHour:  2170212,
+		Healing:        250000,
+		HealingPerHour: 159574,
+		KilledMonsters: []ItemCount{{Name: "dragon lord", Count: 1024}, {Name: "frost dragon", Count: 87}},
+		LootedItems:    []ItemCount{{Name: "a dragon shield", Count: 2}, {Name: "gold coin", Count: 1500}, {Name: "a royal helmet", Count: 1}},
+	}
+	empty := want
+	empty.KilledMonsters, empty.LootedItems = nil, nil
+
+	noItems, _, _ := strings.Cut(huntingSession, "Killed Monsters:")
+	noItems += "Killed Monsters:\n\tNone\nLooted Items:\n\tNone\n"
+
+	tests := []struct {
+		name  string
+		input string
+		want  HuntingSession
+	}{
+		{"pasted from the game", huntingSession, want},
+		{"single line", singleLine(huntingSession), want},
+		{"empty lists", noItems, empty},
+		{"empty lists on a single line", singleLine(noItems), empty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := ParseHuntingSession(tt.input)
+			if err != nil {
+				t.Fatalf("ParseHuntingSession() error = %v", err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("ParseHuntingSession() warnings = %v", warnings)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHuntingSession() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHuntingSessionItems(t *testing.T) {
+	header, _, _ := strings.Cut(huntingSession, "Killed Monsters:")
+
+	tests := []struct {
+		name    string
+		items   string
+		want    []ItemCount
+		wantErr string
+	}{
+		{
+			name:  "names with several words and counts",
+			items: "Killed Monsters:\n\t3x the first dragon of 5 heads\n\t2x Xx Sir Elite xX\nLooted Items:\n\tNone\n",
+			want:  []ItemCount{{Name: "the first dragon of 5 heads", Count: 3}, {Name: "Xx Sir Elite xX", Count: 2}},
+		},
+		{
+			name:    "count without a name",
+			items:   "Killed Monsters:\n\t3x\n\t2x dragon\nLooted Items:\n\tNone\n",
+			wantErr: "item name is missing",
+		},
+		{
+			name:    "count without a name at the end",
+			items:   "Killed Monsters:\n\t2x dragon\n\t3x\nLooted Items:\n\tNone\n",
+			wantErr: "item name is missing",
+		},
+		{
+			name:    "name without a count",
+			items:   "Killed Monsters:\n\tdragon\nLooted Items:\n\tNone\n",
+			wantErr: `expected an item count, got "dragon"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := ParseHuntingSession(header + tt.items)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseHuntingSession() error = %v", err)
+				}
+				if !reflect.DeepEqual(got.KilledMonsters, tt.want) {
+					t.Errorf("KilledMonsters = %+v, want %+v", got.KilledMonsters, tt.want)
+				}
+				return
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseHuntingSession() error = %v, want a *ParseError", err)
+			}
+			if parseErr.Field != "Killed Monsters:" || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseHuntingSession() error = %v, want %q in Killed Monsters:", err, tt.wantErr)
+			}
+		})
+	}
+}
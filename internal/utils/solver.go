@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Solver chooses how the owed and received amounts are matched into transfers
+type Solver int
+
+const (
+	SolverGreedy Solver = iota
+	SolverExact
+)
+
+// exactSolverLimit is the number of unsettled players above which the exact
+// solver falls back to the greedy matcher
+const exactSolverLimit = 12
+
+var Solvers = []Solver{
+	SolverGreedy,
+	SolverExact,
+}
+
+func (s Solver) String() string {
+	switch s {
+	case SolverGreedy:
+		return "greedy"
+	case SolverExact:
+		return "exact"
+	default:
+		return fmt.Sprintf("Solver(%d)", int(s))
+	}
+}
+
+// Description is the transfer matching choice, with the trade-off of each
+// solver and the party size the exact one handles
+func (s Solver) Description() string {
+	switch s {
+	case SolverGreedy:
+		return "Greedy (fast, usually minimal)"
+	case SolverExact:
+		return fmt.Sprintf("Exact minimum (up to %d players)", exactSolverLimit)
+	default:
+		return s.String()
+	}
+}
+
+// ParseSolver reads the --solver flag, greedy or exact
+func ParseSolver(s string) (Solver, error) {
+	for _, solver := range Solvers {
+		if strings.EqualFold(s, solver.String()) {
+			return solver, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown solver %q", s)
+}
+
+// WithSolver chooses how transfers are matched. The default is the greedy
+// matcher.
+func WithSolver(solver Solver) SplitOption {
+	return func(c *splitConfig) {
+		c.solver = solver
+	}
+}
+
+func (s Solver) transfers(playerTransfers []PlayerTransfer) []DirectTransfer {
+	if s == SolverExact {
+		return calculateMinimalTransfers(playerTransfers)
+	}
+	return calculateDirectTransfers(playerTransfers)
+}
+
+// calculateMinimalTransfers finds the true minimum number of transfers by
+// partitioning the unsettled players into as many zero-sum groups as possible.
+// A group of n players always settles in n-1 transfers, so each extra group
+// saves one transfer. Large parties fall back to the greedy matcher.
+func calculateMinimalTransfers(playerTransfers []PlayerTransfer) []DirectTransfer {
+	var unsettled []PlayerTransfer
+	for _, pt := range playerTransfers {
+		if pt.TransferAmount != 0 {
+			unsettled = append(unsettled, pt)
+		}
+	}
+
+	n := len(unsettled)
+	if n > exactSolverLimit {
+		return calculateDirectTransfers(playerTransfers)
+	}
+
+	full := 1<<n - 1
+	sums := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		low := mask & -mask
+		sums[mask] = sums[mask^low] + unsettled[bitIndex(low)].TransferAmount
+	}
+	if sums[full] != 0 {
+		return calculateDirectTransfers(playerTransfers)
+	}
+
+	// groups[mask] is the most zero-sum groups a zero-sum mask splits into and
+	// choice[mask] the group holding its lowest player in that partition
+	groups := make([]int, full+1)
+	choice := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		if sums[mask] != 0 {
+			continue
+		}
+		low := mask & -mask
+		rest := mask ^ low
+		for sub := rest; ; sub = (sub - 1) & rest {
+			group := sub | low
+			if sums[group] == 0 && 1+groups[mask^group] > groups[mask] {
+				groups[mask] = 1 + groups[mask^group]
+				choice[mask] = group
+			}
+			if sub == 0 {
+				break
+			}
+		}
+	}
+
+	var transfers []DirectTransfer
+	for mask := full; mask != 0; mask ^= choice[mask] {
+		var group []PlayerTransfer
+		for i := range unsettled {
+			if choice[mask]&(1<<i) != 0 {
+				group = append(group, unsettled[i])
+			}
+		}
+		transfers = append(transfers, calculateDirectTransfers(group)...)
+	}
+	return transfers
+}
+
+// bitIndex returns the position of the single bit set in b
+func bitIndex(b int) int {
+	i := 0
+	for b > 1 {
+		b >>= 1
+		i++
+	}
+	return i
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitStrategy decides the final balance each player should end up with.
+// Targets receives the share of every player in thousandths and the excluded
+// players that take part in the split, and returns the targets of both
+// rounded down. The pot is the players' combined balance plus whatever the
+// excluded players hand over; CalculateGoldSplit hands out what the targets
+// leave of it with the remainder policy and matches the transfers.
+type SplitStrategy interface {
+	// Name identifies the strategy on the command line and in the history
+	Name() string
+	// Description is the strategy as offered on the split options screen
+	Description() string
+	// Exclusion returns how the strategy settles excluded players when the
+	// user picked mode
+	Exclusion(mode ExclusionMode) ExclusionMode
+	Targets(players []Player, units []int, excluded []Player, mode ExclusionMode) (targets, excludedTargets []int)
+}
+
+// EqualBalance splits the combined balance by share, so every player ends up
+// with the same profit or waste per share. It is the default strategy.
+type EqualBalance struct{}
+
+func (EqualBalance) Name() string { return "equal" }
+
+func (EqualBalance) Description() string { return "Split the balance equally" }
+
+func (EqualBalance) Exclusion(mode ExclusionMode) ExclusionMode { return mode }
+
+func (EqualBalance) Targets(players []Player, units []int, excluded []Player, mode ExclusionMode) ([]int, []int) {
+	var pot, totalUnits int
+	for i, player := range players {
+		pot += player.Balance
+		totalUnits += units[i]
+	}
+
+	excludedTargets := make([]int, len(excluded))
+	for i, player := range excluded {
+		excludedTargets[i] = mode.target(player)
+		pot += player.Balance - excludedTargets[i]
+	}
+
+	targets := make([]int, len(players))
+	for i := range players {
+		targets[i] = floorDiv(pot*units[i], totalUnits)
+	}
+	return targets, excludedTargets
+}
+
+// ReimburseFirst pays everyone's supplies back out of the combined loot first,
+// excluded players included, and splits only what is left by share among the
+// players in the split. Excluded players therefore always hand over their loot
+// and get their supplies refunded, whatever exclusion mode was picked. When
+// the loot doesn't cover the supplies, everyone is refunded the same fraction
+// of what they spent instead of the players taking an equal part of the waste,
+// and share weights don't apply.
+type ReimburseFirst struct{}
+
+func (ReimburseFirst) Name() string { return "reimburse" }
+
+func (ReimburseFirst) Description() string {
+	return "Refund everyone's supplies first, then split the rest"
+}
+
+func (ReimburseFirst) Exclusion(ExclusionMode) ExclusionMode { return ExcludeRefundSupplies }
+
+func (ReimburseFirst) Targets(players []Player, units []int, excluded []Player, _ ExclusionMode) ([]int, []int) {
+	// The pot is everyone's loot minus everyone's supplies
+	var pot, supplies, totalUnits int
+	for i, player := range players {
+		pot += player.Balance
+		supplies += player.Supplies
+		totalUnits += units[i]
+	}
+	for _, player := range excluded {
+		pot += player.Balance
+		supplies += player.Supplies
+	}
+
+	targets := make([]int, len(players))
+	excludedTargets := make([]int, len(excluded))
+
+	// With a profit everyone is refunded in full, leaving excluded players
+	// even, and the rest is split by share
+	if pot >= 0 || supplies <= 0 {
+		for i := range players {
+			targets[i] = floorDiv(pot*units[i], totalUnits)
+		}
+		return targets, excludedTargets
+	}
+
+	// Otherwise the loot refunds the same fraction of everyone's supplies,
+	// so each player bears the waste in proportion to what they spent
+	for i, player := range players {
+		targets[i] = floorDiv(pot*player.Supplies, supplies)
+	}
+	for i, player := range excluded {
+		excludedTargets[i] = floorDiv(pot*player.Supplies, supplies)
+	}
+	return targets, excludedTargets
+}
+
+var SplitStrategies = []SplitStrategy{
+	EqualBalance{},
+	ReimburseFirst{},
+}
+
+// ParseSplitStrategy looks up the strategy given to --strategy, ignoring case
+func ParseSplitStrategy(s string) (SplitStrategy, error) {
+	for _, strategy := range SplitStrategies {
+		if strings.EqualFold(s, strategy.Name()) {
+			return strategy, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown split strategy %q", s)
+}
+
+// WithStrategy chooses how the balance is shared. The default is EqualBalance.
+func WithStrategy(strategy SplitStrategy) SplitOption {
+	return func(c *splitConfig) {
+		c.strategy = strategy
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStrategies(t *testing.T) {
+	players := []Player{
+		{Name: "Knight", Leader: true, Loot: 300, Supplies: 100, Balance: 200},
+		{Name: "Druid", Loot: 100, Supplies: 100, Balance: 0},
+	}
+	guest := []Player{{Name: "Guest", Loot: 200, Supplies: 50, Balance: 150}}
+	wasteGuest := []Player{{Name: "Guest", Loot: 0, Supplies: 200, Balance: -200}}
+
+	tests := []struct {
+		name      string
+		strategy  SplitStrategy
+		excluded  []Player
+		mode      ExclusionMode
+		want      map[string]int
+		wantTotal int
+	}{
+		{
+			name:      "equal ignores the guest",
+			strategy:  EqualBalance{},
+			excluded:  guest,
+			want:      map[string]int{"Knight": 100, "Druid": 100},
+			wantTotal: 200,
+		},
+		{
+			name:      "reimburse refunds the guest out of the combined loot",
+			strategy:  ReimburseFirst{},
+			excluded:  guest,
+			want:      map[string]int{"Knight": 175, "Druid": 175, "Guest": 0},
+			wantTotal: 350,
+		},
+		{
+			name:      "reimburse matches equal refunding the guest",
+			strategy:  EqualBalance{},
+			excluded:  guest,
+			mode:      ExcludeRefundSupplies,
+			want:      map[string]int{"Knight": 175, "Druid": 175, "Guest": 0},
+			wantTotal: 350,
+		},
+		{
+			name:      "equal makes the players pay for the guest refund",
+			strategy:  EqualBalance{},
+			excluded:  wasteGuest,
+			mode:      ExcludeRefundSupplies,
+			want:      map[string]int{"Knight": 0, "Druid": 0, "Guest": 0},
+			wantTotal: 0,
+		},
+		{
+			name:      "reimburse shares the waste by supplies, the leader keeps the remainder",
+			strategy:  ReimburseFirst{},
+			excluded:  []Player{{Name: "Guest", Loot: 0, Supplies: 400, Balance: -400}},
+			want:      map[string]int{"Knight": -32, "Druid": -34, "Guest": -134},
+			wantTotal: -66,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := CalculateGoldSplit(players,
+				WithStrategy(tt.strategy),
+				WithExcluded(tt.excluded, tt.mode))
+
+			if got := finalBalances(split); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("final balances = %v, want %v", got, tt.want)
+			}
+			if split.TotalBalance != tt.wantTotal {
+				t.Errorf("TotalBalance = %d, want %d", split.TotalBalance, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplate is the built-in layout of the clipboard text. Copy it to
+// clipboard.tmpl in the data directory to change the wording.
+const DefaultTemplate = `=== LOOT SPLIT RESULTS ===
+
+{{range .DirectTransfers -}}
+{{.From}} to pay {{.To}} {{FormatNumber .Amount}}   |   bank: {{.BankCommand}}
+
+{{end}}
+{{.Split.TotalLabel}}: {{FormatNumber (abs .Split.TotalBalance)}} {{"\n"}}
+{{- if .Split.HasShare}}{{.Split.ShareLabel}}: {{FormatNumber (abs .Split.EqualShare)}} {{"\n"}}{{end}}
+{{- with .Split.StrategyDescription}}strategy: {{.}} {{"\n"}}{{end}}
+{{- if .Split.Contribution.Enabled}}split: {{.Split.Contribution}} {{"\n"}}{{end}}
+{{- with .Split.ExcludedNames}}excluded ({{lower $.Split.ExclusionMode.Description}}): {{join . ", "}} {{"\n"}}{{end}}
+{{- if .Split.PerPlayer}}{{range .Split.Shareholders}}{{$.Split.PlayerLabel .}}: {{FormatNumber .FinalBalance}} {{"\n"}}{{end}}{{end}}
+{{- if gt .Split.Remainder 0}}remainder: {{.Split.Remainder}} gp to {{join .Split.RemainderRecipients ", "}} {{"\n"}}{{end}}
+{{- with .Metrics}}{{"\n"}}{{range .MetricLines}}{{index . 0}}: {{index . 1}} {{"\n"}}{{end}}{{end}}`
+
+// TemplateData is what clipboard templates are executed with
+type TemplateData struct {
+	Party           Party
+	Split           GoldSplit
+	DirectTransfers []DirectTransfer
+	// Metrics are the per-hour rates, or nil when the duration is unknown
+	Metrics *HuntMetrics
+}
+
+// templateFuncs are the functions available to clipboard templates
+var templateFuncs = template.FuncMap{
+	"FormatNumber": FormatNumber,
+	"abs":          abs,
+	"join":         strings.Join,
+	"lower":        strings.ToLower,
+	"upper":        strings.ToUpper,
+}
+
+var defaultTemplate = template.Must(template.New("default").Funcs(templateFuncs).Parse(DefaultTemplate))
+
+// LoadClipboardTemplate reads clipboard.tmpl from the data directory,
+// falling back to DefaultTemplate when there is none or no data directory
+func LoadClipboardTemplate() (*template.Template, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return defaultTemplate, nil
+	}
+
+	path := filepath.Join(dir, "clipboard.tmpl")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultTemplate, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clipboard template: %v", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse clipboard template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate executes a clipboard template for a split
+func RenderTemplate(tmpl *template.Template, party Party, split GoldSplit) (string, error) {
+	data := TemplateData{
+		Party:           party,
+		Split:           split,
+		DirectTransfers: split.DirectTransfers,
+	}
+	if metrics, ok := split.Metrics(); ok {
+		data.Metrics = &metrics
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render clipboard template: %v", err)
+	}
+	return sb.String(), nil
+}
+
+// RenderClipboard renders a split with the user's clipboard template, or the
+// default layout when they have none
+func RenderClipboard(party Party, split GoldSplit) (string, error) {
+	tmpl, err := LoadClipboardTemplate()
+	if err != nil {
+		return "", err
+	}
+	return RenderTemplate(tmpl, party, split)
+}
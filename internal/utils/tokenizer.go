@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	// tokenLabel is one of the known "Field:" labels of an analyzer
+	tokenLabel tokenKind = iota
+	// tokenWord is any other whitespace separated word
+	tokenWord
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// tokenize splits an analyzer into labels and words. Labels are only
+// recognised at the start of a word, so values such as "14:30:21" or player
+// names containing "Loot" stay plain words. Line breaks carry no meaning,
+// which lets multi-line and single-line pastes parse the same way.
+func tokenize(input string, labels []string) []token {
+	// Try longer labels first so "Loot Type:" wins over "Loot:"
+	labels = append([]string(nil), labels...)
+	sort.SliceStable(labels, func(i, j int) bool {
+		return len(labels[i]) > len(labels[j])
+	})
+
+	var tokens []token
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		if label, ok := matchLabel(input[i:], labels); ok {
+			tokens = append(tokens, token{kind: tokenLabel, text: label, offset: i})
+			i += len(label)
+			continue
+		}
+
+		end := i
+		for end < len(input) {
+			r, size := utf8.DecodeRuneInString(input[end:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+		tokens = append(tokens, token{kind: tokenWord, text: input[i:end], offset: i})
+		i = end
+	}
+	return tokens
+}
+
+func matchLabel(s string, labels []string) (string, bool) {
+	for _, label := range labels {
+		if len(s) >= len(label) && s[:len(label)] == label {
+			return label, true
+		}
+	}
+	return "", false
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/afonso-borges/t-hub/internal/themes"
 	"github.com/charmbracelet/lipgloss"
@@ -11,20 +13,39 @@ import (
 
 type PlayerTransfer struct {
 	Player
+	Share          float64
+	Remainder      int
 	TransferAmount int
 	FinalBalance   int
 	Status         string
+	// Excluded players take no share and only settle what their exclusion
+	// mode asks of them
+	Excluded bool `json:",omitempty"`
 }
 
 type DirectTransfer struct {
+	ID     string
 	From   string
 	To     string
 	Amount int
 }
 
+// BankCommand is what the payer says to a bank NPC to send the transfer
+func (t DirectTransfer) BankCommand() string {
+	return fmt.Sprintf("transfer %d to %s", t.Amount, t.To)
+}
+
 type GoldSplit struct {
 	TotalBalance    int
 	EqualShare      int
+	Weighted        bool
+	Remainder       int
+	RemainderPolicy RemainderPolicy
+	Strategy        string
+	ExclusionMode   ExclusionMode
+	Contribution    ContributionBlend
+	// Duration is how long the hunt took, or 0 when unknown
+	Duration        time.Duration
 	PlayerTransfers []PlayerTransfer
 	DirectTransfers []DirectTransfer
 	Summary         TransferSummary
@@ -38,50 +59,169 @@ type TransferSummary struct {
 	TransferCount    int
 }
 
-func CalculateGoldSplit(players []Player) GoldSplit {
+// SplitOption configures how CalculateGoldSplit shares the party balance
+type SplitOption func(*splitConfig)
+
+type splitConfig struct {
+	weights   map[string]float64
+	remainder RemainderPolicy
+	solver    Solver
+	strategy  SplitStrategy
+	duration  time.Duration
+	excluded  []Player
+	exclusion ExclusionMode
+	// contribution scales the shares by damage and healing when enabled
+	contribution ContributionBlend
+}
+
+// WithWeights splits the balance proportionally to each player's share weight
+// instead of equally. Players missing from weights, or with a non-positive
+// weight, take a single share.
+func WithWeights(weights map[string]float64) SplitOption {
+	return func(c *splitConfig) {
+		c.weights = weights
+	}
+}
+
+// WithRemainderPolicy chooses who keeps the gold left over by rounding the
+// shares down. The default gives it to the party leader.
+func WithRemainderPolicy(policy RemainderPolicy) SplitOption {
+	return func(c *splitConfig) {
+		c.remainder = policy
+	}
+}
+
+func (c splitConfig) share(name string) float64 {
+	if w, ok := c.weights[name]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// shareUnits converts a share weight into whole thousandths of a share so the
+// split can be computed with exact integer math
+func shareUnits(w float64) int {
+	return max(int(math.Round(w*1000)), 1)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// floorDiv divides rounding towards negative infinity, so waste hunts round
+// down like profitable ones and the remainder is never negative
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func CalculateGoldSplit(players []Player, opts ...SplitOption) GoldSplit {
+	if len(players) == 0 {
+		return GoldSplit{}
+	}
+
+	cfg := splitConfig{strategy: EqualBalance{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	var totalBalance int
-	for _, player := range players {
+	var totalUnits int
+	var weighted bool
+	shares := make([]float64, len(players))
+	units := make([]int, len(players))
+	var factors []float64
+	if cfg.contribution.Enabled() {
+		factors = cfg.contribution.factors(players)
+	}
+	for i, player := range players {
 		totalBalance += player.Balance
+		shares[i] = cfg.share(player.Name)
+		if factors != nil {
+			shares[i] = math.Round(shares[i]*factors[i]*1000) / 1000
+		}
+		units[i] = shareUnits(shares[i])
+		totalUnits += units[i]
+		weighted = weighted || shares[i] != 1
+	}
+
+	// Whatever excluded players hand over is shared by the rest of the party
+	exclusion := cfg.strategy.Exclusion(cfg.exclusion)
+	var excluded []Player
+	if exclusion != ExcludeIgnore {
+		excluded = cfg.excluded
+	}
+	targets, excludedTargets := cfg.strategy.Targets(players, units, excluded, exclusion)
+	for i, player := range excluded {
+		totalBalance += player.Balance - excludedTargets[i]
 	}
-	playerCount := len(players)
-	equalShare := totalBalance / playerCount
+	equalShare := floorDiv(totalBalance*shareUnits(1), totalUnits)
+
+	// The strategy rounds every target down; hand out what is left so no
+	// coin is lost
+	remainder := totalBalance
+	for _, target := range targets {
+		remainder -= target
+	}
+	extra := distributeRemainder(players, targets, remainder, cfg.remainder)
 
 	var playerTransfers []PlayerTransfer
 
 	var summary TransferSummary
 
 	// Calculate individual transfer amount
-	for _, player := range players {
-		transferAmount := player.Balance - equalShare
-		finalBalance := equalShare
-
-		var status string
-		if transferAmount > 0 {
-			status = "owes"
-			summary.TotalOwed += transferAmount
+	addTransfer := func(pt PlayerTransfer) {
+		pt.TransferAmount = pt.Balance - pt.FinalBalance
+
+		if pt.TransferAmount > 0 {
+			pt.Status = "owes"
+			summary.TotalOwed += pt.TransferAmount
 			summary.PlayersOwing++
-		} else if transferAmount < 0 {
-			status = "receives"
-			summary.TotalReceived += -transferAmount
+		} else if pt.TransferAmount < 0 {
+			pt.Status = "receives"
+			summary.TotalReceived += -pt.TransferAmount
 			summary.PlayersReceiving++
 		} else {
-			status = "balanced"
+			pt.Status = "balanced"
 		}
 
-		playerTransfers = append(playerTransfers, PlayerTransfer{
-			Player:         player,
-			TransferAmount: transferAmount,
-			FinalBalance:   finalBalance,
-			Status:         status,
+		playerTransfers = append(playerTransfers, pt)
+	}
+	for i, player := range players {
+		addTransfer(PlayerTransfer{
+			Player:       player,
+			Share:        shares[i],
+			Remainder:    extra[i],
+			FinalBalance: targets[i],
+		})
+	}
+	for i, player := range excluded {
+		addTransfer(PlayerTransfer{
+			Player:       player,
+			Excluded:     true,
+			FinalBalance: excludedTargets[i],
 		})
 	}
 
-	directTransfers := calculateDirectTransfers(playerTransfers)
+	directTransfers := cfg.solver.transfers(playerTransfers)
 	summary.TransferCount = len(directTransfers)
 
 	return GoldSplit{
 		TotalBalance:    totalBalance,
 		EqualShare:      equalShare,
+		Weighted:        weighted,
+		Remainder:       remainder,
+		RemainderPolicy: cfg.remainder,
+		Strategy:        cfg.strategy.Name(),
+		ExclusionMode:   exclusion,
+		Contribution:    cfg.contribution,
+		Duration:        cfg.duration,
 		PlayerTransfers: playerTransfers,
 		DirectTransfers: directTransfers,
 		Summary:         summary,
@@ -138,6 +278,110 @@ func calculateDirectTransfers(playerTransfers []PlayerTransfer) []DirectTransfer
 	return transfers
 }
 
+// IsWaste reports whether the party lost gold on the hunt, in which case the
+// transfers refund the players who paid for more than their part of the supplies
+func (s GoldSplit) IsWaste() bool {
+	return s.TotalBalance < 0
+}
+
+// TotalLabel and ShareLabel name the totals of the split, switching to waste
+// wording when the hunt was not profitable
+func (s GoldSplit) TotalLabel() string {
+	if s.IsWaste() {
+		return "total waste"
+	}
+	return "total profit"
+}
+
+func (s GoldSplit) ShareLabel() string {
+	switch {
+	case s.IsWaste() && s.Weighted:
+		return "waste of one share"
+	case s.IsWaste():
+		return "waste per player"
+	case s.Weighted:
+		return "value of one share"
+	default:
+		return "total for each player"
+	}
+}
+
+// RemainderRecipients returns the names of the players who kept the remainder
+func (s GoldSplit) RemainderRecipients() []string {
+	var names []string
+	for _, pt := range s.PlayerTransfers {
+		if pt.Remainder > 0 {
+			names = append(names, pt.Name)
+		}
+	}
+	return names
+}
+
+// Shareholders returns the transfers of the players who took a share, leaving
+// out excluded players
+func (s GoldSplit) Shareholders() []PlayerTransfer {
+	var shareholders []PlayerTransfer
+	for _, pt := range s.PlayerTransfers {
+		if !pt.Excluded {
+			shareholders = append(shareholders, pt)
+		}
+	}
+	return shareholders
+}
+
+// ExcludedNames returns the names of the excluded players settled by the split
+func (s GoldSplit) ExcludedNames() []string {
+	var names []string
+	for _, pt := range s.PlayerTransfers {
+		if pt.Excluded {
+			names = append(names, pt.Name)
+		}
+	}
+	return names
+}
+
+// PerPlayer reports whether the players end up with different amounts, so
+// the results list each player's balance. That happens with share weights
+// and with strategies that don't split the balance evenly.
+func (s GoldSplit) PerPlayer() bool {
+	if s.Weighted {
+		return true
+	}
+	balances := make(map[int]bool)
+	for _, pt := range s.Shareholders() {
+		balances[pt.FinalBalance-pt.Remainder] = true
+	}
+	return len(balances) > 1
+}
+
+// HasShare reports whether the split has a single share value, which isn't
+// the case when the strategy leaves players with uneven balances
+func (s GoldSplit) HasShare() bool {
+	return s.Weighted || !s.PerPlayer()
+}
+
+// PlayerLabel names a player in the per-player balances, with their share
+// when the split is weighted
+func (s GoldSplit) PlayerLabel(pt PlayerTransfer) string {
+	if s.Weighted {
+		return fmt.Sprintf("%s (%gx)", pt.Name, pt.Share)
+	}
+	return pt.Name
+}
+
+// StrategyDescription describes the strategy used when it isn't the default
+// equal split, or returns "" otherwise
+func (s GoldSplit) StrategyDescription() string {
+	if s.Strategy == "" || s.Strategy == (EqualBalance{}).Name() {
+		return ""
+	}
+	strategy, err := ParseSplitStrategy(s.Strategy)
+	if err != nil {
+		return s.Strategy
+	}
+	return strategy.Description()
+}
+
 func DisplayTransfers(split GoldSplit) {
 	fmt.Print(FormatTransfers(split))
 }
@@ -171,11 +415,42 @@ func FormatTransfers(split GoldSplit) string {
 
 	fmt.Fprintf(&sb, "\n")
 	fmt.Fprintf(&sb, "%s %s\n",
-		dkw("total profit: "),
-		kw(fmt.Sprintf("%d gp", split.TotalBalance)))
-	fmt.Fprintf(&sb, "%s %s\n",
-		dkw("total for each player: "),
-		kw(fmt.Sprintf("%d gp", split.EqualShare)))
+		dkw(split.TotalLabel()+": "),
+		kw(fmt.Sprintf("%d gp", abs(split.TotalBalance))))
+	if split.HasShare() {
+		fmt.Fprintf(&sb, "%s %s\n",
+			dkw(split.ShareLabel()+": "),
+			kw(fmt.Sprintf("%d gp", abs(split.EqualShare))))
+	}
+	if strategy := split.StrategyDescription(); strategy != "" {
+		fmt.Fprintf(&sb, "%s %s\n", dkw("strategy: "), kw(strategy))
+	}
+	if split.Contribution.Enabled() {
+		fmt.Fprintf(&sb, "%s %s\n", dkw("split: "), kw(split.Contribution.String()))
+	}
+	if names := split.ExcludedNames(); len(names) > 0 {
+		fmt.Fprintf(&sb, "%s %s\n",
+			dkw(fmt.Sprintf("excluded (%s): ", strings.ToLower(split.ExclusionMode.Description()))),
+			kw(strings.Join(names, ", ")))
+	}
+	if split.PerPlayer() {
+		for _, pt := range split.Shareholders() {
+			fmt.Fprintf(&sb, "%s %s\n",
+				dkw(split.PlayerLabel(pt)+": "),
+				kw(fmt.Sprintf("%d gp", pt.FinalBalance)))
+		}
+	}
+	if split.Remainder > 0 {
+		fmt.Fprintf(&sb, "%s %s\n",
+			dkw(fmt.Sprintf("remainder (%s): ", split.RemainderPolicy)),
+			kw(fmt.Sprintf("%d gp to %s", split.Remainder, strings.Join(split.RemainderRecipients(), ", "))))
+	}
+	if metrics, ok := split.Metrics(); ok {
+		fmt.Fprintf(&sb, "\n")
+		for _, line := range metrics.MetricLines() {
+			fmt.Fprintf(&sb, "%s %s\n", dkw(line[0]+": "), kw(line[1]))
+		}
+	}
 
 	return sb.String()
 }
@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// finalBalances maps every player of a split to the balance they end up with
+func finalBalances(split GoldSplit) map[string]int {
+	balances := make(map[string]int)
+	for _, pt := range split.PlayerTransfers {
+		balances[pt.Name] = pt.FinalBalance
+	}
+	return balances
+}
+
+func TestCalculateGoldSplitRemainder(t *testing.T) {
+	players := []Player{
+		{Name: "A", Balance: 101, Damage: 10},
+		{Name: "B", Leader: true, Damage: 5},
+		{Name: "C", Damage: 50},
+	}
+	waste := []Player{
+		{Name: "A", Balance: -100, Damage: 10},
+		{Name: "B", Leader: true, Damage: 5},
+		{Name: "C", Damage: 50},
+	}
+
+	tests := []struct {
+		name          string
+		players       []Player
+		policy        RemainderPolicy
+		want          map[string]int
+		wantRemainder int
+	}{
+		{"leader", players, RemainderToLeader, map[string]int{"A": 33, "B": 35, "C": 33}, 2},
+		{"top damage", players, RemainderToTopDamage, map[string]int{"A": 33, "B": 33, "C": 35}, 2},
+		{"round-robin", players, RemainderRoundRobin, map[string]int{"A": 34, "B": 34, "C": 33}, 2},
+		{"payer", players, RemainderToPayer, map[string]int{"A": 35, "B": 33, "C": 33}, 2},
+		{"waste rounds down", waste, RemainderToLeader, map[string]int{"A": -34, "B": -32, "C": -34}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := CalculateGoldSplit(tt.players, WithRemainderPolicy(tt.policy))
+			if got := finalBalances(split); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("final balances = %v, want %v", got, tt.want)
+			}
+			if split.Remainder != tt.wantRemainder {
+				t.Errorf("Remainder = %d, want %d", split.Remainder, tt.wantRemainder)
+			}
+		})
+	}
+}
+
+func TestCalculateGoldSplitExclusion(t *testing.T) {
+	players := []Player{
+		{Name: "A", Leader: true, Loot: 150, Supplies: 50, Balance: 100},
+		{Name: "B", Loot: 100, Balance: 100},
+	}
+	guest := []Player{{Name: "Guest", Loot: 60, Supplies: 20, Balance: 40}}
+
+	tests := []struct {
+		name      string
+		mode      ExclusionMode
+		want      map[string]int
+		wantTotal int
+	}{
+		{"ignore", ExcludeIgnore, map[string]int{"A": 100, "B": 100}, 200},
+		{"hand over loot", ExcludeHandOverLoot, map[string]int{"A": 130, "B": 130, "Guest": -20}, 260},
+		{"refund supplies", ExcludeRefundSupplies, map[string]int{"A": 120, "B": 120, "Guest": 0}, 240},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := CalculateGoldSplit(players, WithExcluded(guest, tt.mode))
+			if got := finalBalances(split); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("final balances = %v, want %v", got, tt.want)
+			}
+			if split.TotalBalance != tt.wantTotal {
+				t.Errorf("TotalBalance = %d, want %d", split.TotalBalance, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSolvers(t *testing.T) {
+	tests := []struct {
+		name       string
+		balances   []int
+		wantGreedy int
+		wantExact  int
+	}{
+		{"everyone even", []int{0, 0, 0}, 0, 0},
+		{"one payer", []int{300, -100, -100, -100}, 3, 3},
+		{"matching pairs", []int{100, 100, -100, -100, 300, -300}, 3, 3},
+		{"greedy misses a zero-sum group", []int{800, 200, 500, -1000, -500}, 4, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var players []Player
+			for i, balance := range tt.balances {
+				players = append(players, Player{Name: fmt.Sprintf("P%d", i), Balance: balance})
+			}
+
+			for solver, want := range map[Solver]int{SolverGreedy: tt.wantGreedy, SolverExact: tt.wantExact} {
+				split := CalculateGoldSplit(players, WithSolver(solver))
+				if got := len(split.DirectTransfers); got != want {
+					t.Errorf("%s solver made %d transfers, want %d: %v", solver, got, want, split.DirectTransfers)
+				}
+				checkSettled(t, split)
+			}
+		})
+	}
+}
+
+// checkSettled fails when the direct transfers of a split don't move exactly
+// every player's transfer amount
+func checkSettled(t *testing.T, split GoldSplit) {
+	t.Helper()
+
+	net := make(map[string]int)
+	for _, pt := range split.PlayerTransfers {
+		net[pt.Name] += pt.TransferAmount
+	}
+	for _, transfer := range split.DirectTransfers {
+		if transfer.Amount <= 0 {
+			t.Errorf("transfer %+v is not positive", transfer)
+		}
+		net[transfer.From] -= transfer.Amount
+		net[transfer.To] += transfer.Amount
+	}
+	for name, left := range net {
+		if left != 0 {
+			t.Errorf("%s is left with %d unsettled after the transfers", name, left)
+		}
+	}
+}
+
+// TestSplitProperties checks the invariants of random splits with every
+// combination of options
+func TestSplitProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for range 2000 {
+		var players, excluded []Player
+		weights := make(map[string]float64)
+		for i := range 1 + r.Intn(8) {
+			player := Player{
+				Name:     fmt.Sprintf("P%d", i),
+				Leader:   i == 0,
+				Loot:     r.Intn(2_000_000),
+				Supplies: r.Intn(1_000_000),
+				Damage:   r.Intn(1_000_000),
+				Healing:  r.Intn(1_000_000),
+			}
+			player.Balance = player.Loot - player.Supplies
+			players = append(players, player)
+			if r.Intn(3) == 0 {
+				weights[player.Name] = float64(1+r.Intn(20)) / 10
+			}
+		}
+		for i := range r.Intn(3) {
+			player := Player{Name: fmt.Sprintf("X%d", i), Loot: r.Intn(1_000_000), Supplies: r.Intn(1_000_000)}
+			player.Balance = player.Loot - player.Supplies
+			excluded = append(excluded, player)
+		}
+
+		opts := []SplitOption{
+			WithWeights(weights),
+			WithRemainderPolicy(RemainderPolicies[r.Intn(len(RemainderPolicies))]),
+			WithStrategy(SplitStrategies[r.Intn(len(SplitStrategies))]),
+			WithExcluded(excluded, ExclusionModes[r.Intn(len(ExclusionModes))]),
+			WithContributionBlend(ContributionBlend{Contribution: float64(r.Intn(11)) / 10, Healing: float64(r.Intn(11)) / 10}),
+		}
+		greedy := CalculateGoldSplit(players, append(opts, WithSolver(SolverGreedy))...)
+		exact := CalculateGoldSplit(players, append(opts, WithSolver(SolverExact))...)
+
+		var sum int
+		for _, pt := range greedy.PlayerTransfers {
+			if !pt.Excluded {
+				sum += pt.FinalBalance
+			}
+		}
+		if sum != greedy.TotalBalance {
+			t.Fatalf("final balances add up to %d, want the total balance %d: %+v", sum, greedy.TotalBalance, greedy.PlayerTransfers)
+		}
+		if greedy.Remainder < 0 {
+			t.Fatalf("negative remainder %d", greedy.Remainder)
+		}
+		if !reflect.DeepEqual(greedy.PlayerTransfers, exact.PlayerTransfers) {
+			t.Fatalf("the solver changed the player transfers")
+		}
+
+		checkSettled(t, greedy)
+		checkSettled(t, exact)
+		if len(exact.DirectTransfers) > len(greedy.DirectTransfers) {
+			t.Fatalf("exact solver made %d transfers, more than the %d of the greedy one",
+				len(exact.DirectTransfers), len(greedy.DirectTransfers))
+		}
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+}